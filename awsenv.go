@@ -1,407 +1,740 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"github.com/BernhardLenz/ini"
-	"log"
+	"github.com/BernhardLenz/awsenv/store"
 	"os"
-	"os/user"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
 const VERSION string = "0.1.0-beta"
 
-//TODO: test other keys than aws_access_key_id such as metadata_service_timeout in activate
 //TODO: test printing of default configs as part of a Profile line
-//TODO: test export of e.g. AWS_CCESS_KEY_ID
 //TODO: Add versioning and printing of version
 //TODO: comment methods
 
-type Profile struct {
-	profileName           string
-	aws_access_key_id     string
-	aws_secret_access_key string
-	output                string
-	region                string
-	isActive              bool
-}
-
-var defaultProfile Profile
-var profiles = make(map[string]Profile)
-
-type Config struct {
-	output string
-	region string
-}
-
-var defaultConfig Config
-var configs = make(map[string]Config)
-
-var credentialsFile *ini.File
-var configFile *ini.File
-
-//method pointer which can be changed during test case execution
-var logFatalf = log.Fatalf
-var osExit = os.Exit
-
 func main() {
+	args, verboseFlag := extractVerbose(os.Args[1:])
+	verbose = verboseFlag
 
-	listCommand := flag.NewFlagSet("list", flag.ExitOnError) //since list doesn't require parameters, not sure if a FlagSet is needed
-	activateCommand := flag.NewFlagSet("activate", flag.ExitOnError)
-
-	if len(os.Args) > 3 {
-		fmt.Fprintf(os.Stderr, "ERROR: Too many arguments supplied.\n")
-		printUsage()
-		osExit(1)
-		return //During test case execution osExit may not actually exit
-	}
+	err := run(args)
+	reportError(err)
+	os.Exit(exitCode(err))
+} //main
 
-	if len(os.Args) > 1 {
-		switch os.Args[1] {
+//run executes the command given by the arguments without the program name. Errors are returned
+//to main which prints them and maps them to the exit code.
+func run(osArgs []string) error {
+
+	listCommand := flag.NewFlagSet("list", flag.ExitOnError)
+	listOutput := listCommand.String("output", outputTable, "output format: table, json, yaml or csv")
+	var listOpts listOptions
+	listCommand.StringVar(&listOpts.sortBy, "sort", sortByName, "sort by name, region, active or last-used")
+	listCommand.StringVar(&listOpts.region, "region", "", "only list profiles whose region matches the glob, e.g. eu-*")
+	listCommand.StringVar(&listOpts.nameGlob, "name-glob", "", "only list profiles whose name matches the glob, e.g. prod-*")
+	listCommand.StringVar(&listOpts.kind, "kind", "", "only list profiles of a kind: static, role, sso, process or web-identity")
+	listCommand.BoolVar(&listOpts.activeOnly, "active", false, "only list the active profiles")
+	listWide := listCommand.Bool("wide", false, "don't truncate the table to the width of the terminal")
+	listColumns := listCommand.String("columns", "", "comma separated columns of the table, e.g. name,region,expiry")
+	listNoColor := listCommand.Bool("no-color", false, "don't highlight the active profiles")
+	listIdentity := listCommand.Bool("identity", false, "add the IDENTITY column with the ARN cached by whoami")
+	activateCommand := flag.NewFlagSet("activate", flag.ExitOnError)
+	activateExport := activateCommand.Bool("export", false, "print shell statements exporting the activated Profile instead of the profile list")
+	activateShell := activateCommand.String("shell", "", "shell syntax for --export: bash, zsh, fish, powershell or cmd")
+	activateNoAssume := activateCommand.Bool("no-assume", false, "activate role profiles via the config file instead of assuming the role")
+	activateSwitchConfig := activateCommand.Bool("switch-config", switchConfigDefault(), "also copy region and output of the Profile into the default config, defaults to $AWSENV_SWITCH_CONFIG")
+	envCommand := flag.NewFlagSet("env", flag.ExitOnError)
+	envShell := envCommand.String("shell", "", "shell syntax: bash, zsh, fish, powershell or cmd")
+	useCommand := flag.NewFlagSet("use", flag.ExitOnError)
+	useShell := useCommand.String("shell", "", "shell syntax: bash, zsh, fish, powershell or cmd")
+	useSubshell := useCommand.Bool("subshell", false, "start a new shell using the Profile instead of printing shell statements")
+	execCommand := flag.NewFlagSet("exec", flag.ExitOnError)
+	execOverride := execCommand.Bool("override", false, "replace AWS_* variables which are already set in the environment")
+	backupsCommand := flag.NewFlagSet("backups", flag.ExitOnError)
+	backupsKeep := backupsCommand.Int("keep", 0, "prune: number of newest backups which are always kept")
+	backupsOlderThan := backupsCommand.String("older-than", "", "prune: only delete backups older than e.g. 30d or 12h")
+	showCommand := flag.NewFlagSet("show", flag.ExitOnError)
+	showOutput := showCommand.String("output", outputTable, "output format: table or json")
+	mfaCommand := flag.NewFlagSet("mfa", flag.ExitOnError)
+	mfaDuration := mfaCommand.Int("duration", 0, "validity of the session in seconds, defaults to duration_seconds of the Profile")
+	addCommand := flag.NewFlagSet("add", flag.ExitOnError)
+	var addOpts addOptions
+	addCommand.StringVar(&addOpts.accessKeyId, "access-key-id", "", "access key id, the secret access key is read from stdin if --secret-access-key is not given")
+	addCommand.StringVar(&addOpts.secretAccessKey, "secret-access-key", "", "secret access key")
+	addCommand.StringVar(&addOpts.region, "region", "", "region of the Profile")
+	addCommand.StringVar(&addOpts.output, "output", "", "output format of the Profile")
+	addCommand.StringVar(&addOpts.roleArn, "role-arn", "", "role to assume instead of static credentials")
+	addCommand.StringVar(&addOpts.sourceProfile, "source-profile", "", "Profile whose credentials assume the role")
+	addCommand.StringVar(&addOpts.mfaSerial, "mfa-serial", "", "mfa device used when assuming the role or creating MFA sessions")
+	renameCommand := flag.NewFlagSet("rename", flag.ExitOnError)
+	copyCommand := flag.NewFlagSet("copy", flag.ExitOnError)
+	removeCommand := flag.NewFlagSet("remove", flag.ExitOnError)
+	removeForce := removeCommand.Bool("force", false, "also remove the active Profile")
+	rotateCommand := flag.NewFlagSet("rotate", flag.ExitOnError)
+	processCommand := flag.NewFlagSet("credential-process", flag.ExitOnError)
+	wireCommand := flag.NewFlagSet("wire", flag.ExitOnError)
+	wireTo := wireCommand.String("to", store.DefaultSection, "Profile whose credential_process is set")
+	ssoCommand := flag.NewFlagSet("sso", flag.ExitOnError)
+	whoamiCommand := flag.NewFlagSet("whoami", flag.ExitOnError)
+	findCommand := flag.NewFlagSet("find", flag.ExitOnError)
+	findAccount := findCommand.String("account", "", "12 digit id of the account the access keys belong to")
+	doctorCommand := flag.NewFlagSet("doctor", flag.ExitOnError)
+	doctorFix := doctorCommand.Bool("fix", false, "fix the findings which can be fixed without changing any credentials")
+	ssoRegion := ssoCommand.String("region", "", "sync: region of the created profiles, by default they use the default config")
+
+	var args []string
+	var command []string
+	maxArgs := 1
+	if len(osArgs) > 0 {
+		switch osArgs[0] {
 		case "list":
-			//TODO: handle error
-			_ = listCommand.Parse(make([]string, 0))
+			args = parseArgs(listCommand, osArgs[1:])
 		case "activate":
-			_ = activateCommand.Parse(os.Args[2:])
+			args = parseArgs(activateCommand, osArgs[1:])
+		case "env":
+			args = parseArgs(envCommand, osArgs[1:])
+		case "use":
+			args = parseArgs(useCommand, osArgs[1:])
+		case "exec":
+			args, command = splitCommandArgs(execCommand, osArgs[1:])
+		case "backups":
+			args = parseArgs(backupsCommand, osArgs[1:])
+			maxArgs = 2
+		case "show":
+			args = parseArgs(showCommand, osArgs[1:])
+		case "mfa":
+			args = parseArgs(mfaCommand, osArgs[1:])
+			maxArgs = 2
+		case "add":
+			args = parseArgs(addCommand, osArgs[1:])
+		case "rename":
+			args = parseArgs(renameCommand, osArgs[1:])
+			maxArgs = 2
+		case "copy":
+			args = parseArgs(copyCommand, osArgs[1:])
+			maxArgs = 2
+		case "remove":
+			args = parseArgs(removeCommand, osArgs[1:])
+		case "rotate":
+			args = parseArgs(rotateCommand, osArgs[1:])
+		case "credential-process":
+			args = parseArgs(processCommand, osArgs[1:])
+		case "wire":
+			args = parseArgs(wireCommand, osArgs[1:])
+		case "sso":
+			args = parseArgs(ssoCommand, osArgs[1:])
+			maxArgs = 2
+		case "whoami":
+			args = parseArgs(whoamiCommand, osArgs[1:])
+		case "find":
+			args = parseArgs(findCommand, osArgs[1:])
+			maxArgs = 0
+		case "doctor":
+			args = parseArgs(doctorCommand, osArgs[1:])
+			maxArgs = 0
 		case "help", "-help", "--help":
 			printUsage()
-			osExit(0)
-			return //During test case execution osExit may not actually exit
+			return nil
 		default:
-			fmt.Fprintf(os.Stderr, "ERROR: Unknown command!\n")
-			printUsage()
-			osExit(1)
-			return //During test case execution osExit may not actually exit
+			return &usageError{"Unknown command!"}
 		}
 	}
 
-	if listCommand.Parsed() || len(os.Args) == 1 {
-		parse()
-		listProfiles(profiles)
+	if len(args) > maxArgs {
+		return &usageError{"Too many arguments supplied."}
+	}
+
+	if activateCommand.Parsed() && len(args) == 1 && args[0] == store.DefaultSection {
+		return &store.ProfileError{Kind: store.ErrDefaultNotActivatable, ProfileName: store.DefaultSection}
+	}
+
+	st, err := loadStore()
+	if err != nil {
+		return err
+	}
+
+	if listCommand.Parsed() || len(osArgs) == 0 {
+		if !isSupportedOutput(*listOutput) {
+			return &usageError{fmt.Sprintf("Unsupported output '%s'! Supported outputs are table, json, yaml and csv.", *listOutput)}
+		}
+
+		selected, err := selectProfiles(st, listOpts)
+		if err != nil {
+			return err
+		}
+
+		if *listOutput != outputTable {
+			return writeProfiles(os.Stdout, *listOutput, profileRecords(st, selected))
+		}
+		table := tableOptions{columns: parseColumns(*listColumns), wide: *listWide, color: !*listNoColor}
+		if *listIdentity {
+			table.columns = withIdentityColumn(table.columns)
+		}
+		if err := printProfileTable(st, selected, table); err != nil {
+			return err
+		}
 
 		fmt.Printf("\nTo activate a different Profile run '%s activate <Profile>'", filepath.Base(os.Args[0]))
 	} else if activateCommand.Parsed() {
-		if len(os.Args) != 3 {
-			fmt.Fprintf(os.Stderr, "ERROR: Required parameter <Profile> missing for activate command!\n")
-			printUsage()
-			osExit(1)
-			return //During test case execution osExit may not actually exit
+		if len(args) != 1 {
+			return &usageError{"Required parameter <Profile> missing for activate command!"}
 		}
-		activateProfileName := os.Args[2]
-		if activateProfileName == "default" {
-			logFatalf("ERROR: Cannot activate the 'default' Profile as it is already active!")
+		shell, err := resolveShell(*activateShell)
+		if err != nil {
+			return err
 		}
-		parse()
+		activateProfileName := args[0]
 
-		profile, ok := profiles[activateProfileName]
+		profile, ok := st.Profile(activateProfileName)
 		if !ok {
-			fmt.Fprintf(os.Stderr, "ERROR: Profile '%s' does not exist! Available profiles are: \n\n", activateProfileName)
-			listProfiles(profiles)
-			osExit(1)
-			return //During test case execution osExit may not actually exit
+			return &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: activateProfileName}
 		}
 
-		if profile.isActive {
+		if profile.IsActive {
 			fmt.Fprintf(os.Stderr, "Profile '%s' is already active! No changes applied. \n\n", activateProfileName)
-			listProfiles(profiles)
-			osExit(0)
-			return //During test case execution osExit may not actually exit
+			if *activateExport {
+				printExports(st, shell, profile)
+				return nil
+			}
+			return listProfiles(st)
 		}
 
-		setDefaultProfile(activateProfileName)
-
-		listProfiles(profiles)
+		if profile.Kind == store.KindRole && !*activateNoAssume {
+			creds, err := resolveCredentials(st, activateProfileName)
+			if err != nil {
+				return fmt.Errorf("Failed to assume role of Profile '%s': %w", activateProfileName, err)
+			}
+			err = st.SetDefaultCredentials(creds)
+		} else {
+			err = st.Activate(activateProfileName)
+		}
+		if err == nil && *activateSwitchConfig {
+			err = st.SwitchDefaultConfig(activateProfileName)
+		}
+		if err == nil {
+			err = st.Save()
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to activate Profile '%s': %w", activateProfileName, err)
+		}
 
-		setEnvironmentVariables()
-	}
-} //main
+		if shellProfile := os.Getenv("AWS_PROFILE"); shellProfile != "" && shellProfile != activateProfileName && !*activateExport {
+			fmt.Fprintf(os.Stderr, "WARNING: AWS_PROFILE is set to '%s' which overrides the activated Profile in this shell.\n", shellProfile)
+		}
 
-func init() {
-	ini.DefaultSection = "default"
-}
+		markLastUsed(activateProfileName)
 
-func printUsage() {
-	fmt.Println("")
-	fmt.Println("Usage:")
-	fmt.Println("")
-	fmt.Printf("  %s [list]\n", filepath.Base(os.Args[0]))
-	fmt.Println("      Lists all available profiles.")
-	fmt.Println("")
-	fmt.Printf("  %s activate <Profile>\n", filepath.Base(os.Args[0]))
-	fmt.Println("      Activates a given Profile.")
-	fmt.Println("")
-	fmt.Printf("  %s help\n", filepath.Base(os.Args[0]))
-	fmt.Println("      Displays help information.")
-	fmt.Println("")
-	fmt.Println("To create a new Profile use 'aws configure'.")
-	fmt.Println("")
-	fmt.Println("Version: awsenv " + VERSION)
-} //printUsage
+		if *activateExport {
+			fmt.Fprintf(os.Stderr, "Activated Profile '%s'\n", activateProfileName)
+			profile, _ = st.Profile(activateProfileName)
+			printExports(st, shell, profile)
+		} else {
+			fmt.Printf("Activated Profile '%s'\n\n", activateProfileName)
+			if err := listProfiles(st); err != nil {
+				return err
+			}
+		}
+		//only after the profiles have been listed as the list refers to AWS_PROFILE of the calling shell
+		setEnvironmentVariables(st)
+		return nil
+	} else if envCommand.Parsed() {
+		if len(args) != 1 {
+			return &usageError{"Required parameter <Profile> missing for env command!"}
+		}
+		shell, err := resolveShell(*envShell)
+		if err != nil {
+			return err
+		}
 
-func parse() {
+		profile, ok := st.Profile(args[0])
+		if !ok {
+			return &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: args[0]}
+		}
+		if profile.Kind == store.KindRole {
+			creds, err := resolveCredentials(st, args[0])
+			if err != nil {
+				return fmt.Errorf("Failed to assume role of Profile '%s': %w", args[0], err)
+			}
+			profile = store.WithCredentials(profile, creds)
+		}
+		markLastUsed(args[0])
+		printExports(st, shell, profile)
+	} else if useCommand.Parsed() {
+		if len(args) != 1 {
+			return &usageError{"Required parameter <Profile> missing for use command!"}
+		}
 
-	parseCredentials()
-	parseConfig()
+		profile, ok := st.Profile(args[0])
+		if !ok && args[0] != store.DefaultSection {
+			return &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: args[0]}
+		}
+		profile.Name = args[0]
+		markLastUsed(args[0])
 
-} //parse
+		if *useSubshell {
+			fmt.Fprintf(os.Stderr, "Starting a new shell using Profile '%s'. Type 'exit' to return.\n", args[0])
+			return runSubshell(profile)
+		}
+		shell, err := resolveShell(*useShell)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Using Profile '%s' in the current shell\n", args[0])
+		fmt.Print(formatExports(shell, shellProfileVariables(profile)))
+	} else if execCommand.Parsed() {
+		if len(args) != 1 || len(command) == 0 {
+			return &usageError{"Required parameters <Profile> -- <Command> missing for exec command!"}
+		}
 
-func parseCredentials() {
-	credentialsFile = loadIni(getCredentialFilePath())
-	//During test case execution loadIni may actually return a nil if it doesn't find a file
-	if credentialsFile == nil {
-		return
-	}
+		profile, ok := st.Profile(args[0])
+		if !ok {
+			return &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: args[0]}
+		}
+		markLastUsed(args[0])
+		return execProfile(st, profile, command, *execOverride)
+	} else if backupsCommand.Parsed() {
+		subcommand := "list"
+		if len(args) > 0 {
+			subcommand = args[0]
+		}
 
-	//creates new empty defaultCredentialsSection if it doesn't exist already
-	defaultCredentialsSection := credentialsFile.Section(ini.DefaultSection)
-	defaultProfile.profileName = ini.DefaultSection
-	defaultProfile.aws_access_key_id = defaultCredentialsSection.Key("aws_access_key_id").Value()
-	defaultProfile.aws_secret_access_key = defaultCredentialsSection.Key("aws_secret_access_key").Value()
-
-	for _, credentialsSection := range credentialsFile.Sections() {
-		sectionName := credentialsSection.Name()
-
-		var profile Profile
-		profile.profileName = sectionName
-
-		for _, key := range credentialsSection.Keys() {
-			keyName := key.Name()
-			value := key.Value()
-			if "aws_access_key_id" == keyName {
-				profile.aws_access_key_id = value
-			} else if "aws_secret_access_key" == keyName {
-				profile.aws_secret_access_key = value
+		switch subcommand {
+		case "list":
+			listBackups(st)
+		case "restore":
+			if len(args) != 2 {
+				return &usageError{"Required parameter <Timestamp|latest> missing for backups restore command!"}
+			}
+			backup, err := st.FindBackup(args[1])
+			if errors.Is(err, store.ErrBackupNotFound) {
+				return fmt.Errorf("Backup '%s' does not exist! Run '%s backups list' to see the available backups.", args[1], filepath.Base(os.Args[0]))
+			} else if err != nil {
+				return err
+			}
+			err = st.RestoreBackup(backup)
+			if err == nil {
+				err = st.Save()
+			}
+			if err != nil {
+				return fmt.Errorf("Failed to restore backup '%s': %w", backup.SectionName, err)
+			}
+			fmt.Printf("Restored backup '%s' into the %s section\n\n", backup.SectionName, backup.Section)
+			return listProfiles(st)
+		case "prune":
+			var olderThan time.Duration
+			if *backupsOlderThan != "" {
+				var err error
+				olderThan, err = parseAge(*backupsOlderThan)
+				if err != nil {
+					return &usageError{err.Error()}
+				}
 			}
+			//0 is a valid value of both flags, e.g. --keep 0 prunes all backups
+			given := make(map[string]bool)
+			backupsCommand.Visit(func(f *flag.Flag) { given[f.Name] = true })
+			if !given["keep"] && !given["older-than"] {
+				return &usageError{"backups prune requires --keep <N> and/or --older-than <Age>!"}
+			}
+			pruned := st.PruneBackups(*backupsKeep, olderThan, time.Now())
+			if err := st.Save(); err != nil {
+				return fmt.Errorf("Failed to prune backups: %w", err)
+			}
+			for _, backup := range pruned {
+				fmt.Printf("Deleted backup '%s'\n", backup.SectionName)
+			}
+			fmt.Printf("Deleted %d backup(s)\n", len(pruned))
+		default:
+			return &usageError{fmt.Sprintf("Unknown backups command '%s'!", subcommand)}
 		}
-		if "default" != sectionName {
-			profiles[sectionName] = profile
+	} else if showCommand.Parsed() {
+		if len(args) != 1 {
+			return &usageError{"Required parameter <Profile> missing for show command!"}
 		}
-	}
 
-	//Now mark the profiles that match the default as active
-	activeProfileFound := false
-	for profileName, profile := range profiles {
-		if profile.aws_access_key_id == defaultProfile.aws_access_key_id && profile.aws_access_key_id != "" {
-			profile.isActive = true
-			profiles[profileName] = profile
-			activeProfileFound = true
+		if *showOutput != outputTable && *showOutput != outputJson {
+			return &usageError{fmt.Sprintf("Unsupported output '%s'! Supported outputs are table and json.", *showOutput)}
 		}
-	}
 
-	//if there is no matching Profile then add the default Profile to the profiles map and make it active
-	if !activeProfileFound && defaultProfile.aws_access_key_id != "" {
-		defaultProfile.isActive = true
-		profiles["default"] = defaultProfile
-	}
+		profile, ok := lookupProfile(st, args[0])
+		if !ok {
+			return &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: args[0]}
+		}
+		return writeProfileDetail(os.Stdout, *showOutput, profileDetails(st, profile))
+	} else if mfaCommand.Parsed() {
+		if len(args) != 2 {
+			return &usageError{"Required parameters <Profile> <TokenCode> missing for mfa command!"}
+		}
 
-} //parseCredentials
+		sessionProfileName, err := createMfaSession(st, args[0], args[1], *mfaDuration)
+		if err != nil {
+			return fmt.Errorf("Failed to create MFA session for Profile '%s': %w", args[0], err)
+		}
 
-func parseConfig() {
+		if err := st.Save(); err != nil {
+			return fmt.Errorf("Failed to save MFA session Profile '%s': %w", sessionProfileName, err)
+		}
 
-	configFile = loadIni(getConfigFilePath())
-	//During test case execution loadIni may actually return a nil if it doesn't find a file
-	if configFile == nil {
-		return
-	}
+		fmt.Printf("Created MFA session Profile '%s'\n\n", sessionProfileName)
+		if err := listProfiles(st); err != nil {
+			return err
+		}
+		fmt.Printf("\nTo activate the MFA session run '%s activate %s'", filepath.Base(os.Args[0]), sessionProfileName)
+	} else if addCommand.Parsed() {
+		if len(args) != 1 {
+			return &usageError{"Required parameter <Profile> missing for add command!"}
+		}
 
-	defaultConfigSection := configFile.Section(ini.DefaultSection)
-	defaultConfig.region = defaultConfigSection.Key("region").Value()
-	defaultConfig.output = defaultConfigSection.Key("output").Value()
-
-	for _, configSection := range configFile.Sections() {
-		var config Config
-		sectionName := configSection.Name()
-		for _, key := range configSection.Keys() {
-			keyName := key.Name()
-			value := key.Value()
-
-			if "output" == keyName {
-				config.output = value
-			} else if "region" == keyName {
-				config.region = value
-			}
+		if err := readAddOptions(os.Stdin, &addOpts); err != nil {
+			return err
+		}
+		err := addProfile(st, args[0], addOpts)
+		if err == nil {
+			err = st.Save()
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to add Profile '%s': %w", args[0], err)
+		}
 
-			//	if "default" == sectionName {
-			//		if "output" == keyName {
-			//			defaultConfig.output = value
-			//			defaultProfile.output = value
-			//			if Profile, ok := profiles[ini.DefaultSection]; ok {
-			//				Profile.output = value
-			//				profiles[ini.DefaultSection] = Profile
-			//			}
-			//		} else if "region" == keyName {
-			//			defaultConfig.region = value
-			//			defaultProfile.region = value
-			//			if Profile, ok := profiles[ini.DefaultSection]; ok {
-			//				Profile.region = value
-			//				profiles[ini.DefaultSection] = Profile
-			//			}
-			//		}
-			//	} else {
-			//		Profile, ok := profiles[sectionName]
-			//		if ok {
-			//			if "output" == keyName {
-			//				Profile.output = value
-			//			} else if "region" == keyName {
-			//				Profile.region = value
-			//			}
-			//			profiles[sectionName] = Profile
-			//		}
-			//	}
-			//}
-			configs[sectionName] = config
+		fmt.Printf("Added Profile '%s'\n\n", args[0])
+		return listProfiles(st)
+	} else if renameCommand.Parsed() {
+		if len(args) != 2 {
+			return &usageError{"Required parameters <Profile> <NewProfile> missing for rename command!"}
 		}
-	}
 
-	//Now set the corresponding fields in profiles
-	for sectionName, profile := range profiles {
-		if config, ok := configs[sectionName]; ok {
-			profile.output = config.output
-			profile.region = config.region
-			profiles[sectionName] = profile
+		updated, err := st.RenameProfile(args[0], args[1])
+		if err == nil {
+			err = st.Save()
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to rename Profile '%s': %w", args[0], err)
+		}
+
+		fmt.Printf("Renamed Profile '%s' to '%s'\n", args[0], args[1])
+		for _, sectionName := range updated {
+			fmt.Printf("Updated source_profile of [%s]\n", sectionName)
+		}
+		fmt.Println("")
+		return listProfiles(st)
+	} else if copyCommand.Parsed() {
+		if len(args) != 2 {
+			return &usageError{"Required parameters <Profile> <NewProfile> missing for copy command!"}
 		}
-	}
-	defaultProfile.output = defaultConfig.output
-	defaultProfile.region = defaultConfig.region
-
-	////Mark the profiles matching the default Profile including the default Profile itself
-	////as active
-	//foundProfileMatchingDefault := false
-	//for sectionName, Profile := range profiles {
-	//	if Profile.aws_access_key_id == defaultProfile.aws_access_key_id {
-	//		foundProfileMatchingDefault = true
-	//		Profile.isActive = true
-	//		//set region and output to "" as the default output will be used instead of the
-	//		//values of this Profile
-	//		if sectionName != ini.DefaultSection {
-	//			Profile.region = ""
-	//			Profile.output = ""
-	//		}
-	//		profiles[sectionName] = Profile
-	//		//break
-	//	}
-	//}
-	//
-	////Only a default Profile exists but no matching named Profile
-	////Previously the default Profile wasn't added to the map
-	////but since the default Profile in this case is a "stand alone" Profile
-	////it needs to be added
-	//if !foundProfileMatchingDefault && defaultProfile.isActive {
-	//	profiles["default"] = defaultProfile
-	//}
-
-} //parseConfig
-
-func loadIni(fileName string) *ini.File {
-	file, err := ini.Load(fileName)
-	if err != nil {
-		errMsg := "Failed to find or read file: " + fileName + ". %v"
-		logFatalf(errMsg, err)
-	}
-	return file
-} //loadIni
 
-func getCredentialFilePath() string {
-	return getAwsCliFilePath("AWS_SHARED_CREDENTIALS_FILE", "credentials")
-} //getCredentialFilePath
+		err := st.CopyProfile(args[0], args[1])
+		if err == nil {
+			err = st.Save()
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to copy Profile '%s': %w", args[0], err)
+		}
 
-func getConfigFilePath() string {
-	return getAwsCliFilePath("AWS_CONFIG_FILE", "config")
-} //getConfigFilePath
+		fmt.Printf("Copied Profile '%s' to '%s'\n\n", args[0], args[1])
+		return listProfiles(st)
+	} else if removeCommand.Parsed() {
+		if len(args) != 1 {
+			return &usageError{"Required parameter <Profile> missing for remove command!"}
+		}
 
-func getAwsCliFilePath(ENV string, fileName string) string {
-	ENVVAL := os.Getenv(ENV)
-	if ENVVAL != "" {
-		return ENVVAL
-	}
+		err := removeProfile(st, args[0], *removeForce)
+		if err == nil {
+			err = st.Save()
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to remove Profile '%s': %w", args[0], err)
+		}
 
-	return getUser().HomeDir + "/.aws/" + fileName
-} //getAwsCliFilePath
+		fmt.Printf("Removed Profile '%s'\n\n", args[0])
+		return listProfiles(st)
+	} else if rotateCommand.Parsed() {
+		if len(args) != 1 {
+			return &usageError{"Required parameter <Profile> missing for rotate command!"}
+		}
 
-func getUser() *user.User {
-	usr, err := user.Current()
-	if err != nil {
-		logFatalf("%v", err)
-	}
-	return usr
-} //getUser
-
-func listProfiles(profiles map[string]Profile) {
-
-	activeLength := 2
-	nameLength := 20
-	awsAccessKeyIdLength := 20
-	regionLength := 15
-	outputLength := 10
-
-	fmt.Printf(fs(activeLength), " ")
-	fmt.Printf(fs(nameLength), "PROFILE")
-	fmt.Printf("    ")
-	fmt.Printf(fs(awsAccessKeyIdLength), "AWS_ACCESS_KEY_ID")
-	fmt.Printf("    ")
-	fmt.Printf(fs(regionLength), "REGION")
-	fmt.Printf("    ")
-	fmt.Printf(fs(outputLength), "OUTPUT")
-	fmt.Printf("\n")
-
-	for sectionName, profile := range profiles {
-		if profile.isActive {
-			fmt.Printf("* ")
-		} else {
-			fmt.Printf("  ")
+		oldAccessKeyId := st.CredentialsValue(args[0], "aws_access_key_id")
+		newAccessKeyId, err := rotateAccessKey(st, args[0])
+		if err != nil {
+			return fmt.Errorf("Failed to rotate the access key of Profile '%s': %w", args[0], err)
+		}
+
+		fmt.Printf("Rotated the access key of Profile '%s': %s has been replaced by %s\n\n", args[0],
+			maskAccessKey(oldAccessKeyId, 20), maskAccessKey(newAccessKeyId, 20))
+		return listProfiles(st)
+	} else if processCommand.Parsed() {
+		if len(args) != 1 {
+			return &usageError{"Required parameter <Profile> missing for credential-process command!"}
 		}
-		fmt.Printf(fs(nameLength), sectionName)
-		truncPrintf(sectionName, nameLength)
 
-		fmt.Printf(fs(awsAccessKeyIdLength), maskAccessKey(profile.aws_access_key_id, awsAccessKeyIdLength))
-		fmt.Printf("    ") //perhaps only one blank here
+		//only the credentials may be written to stdout
+		creds, err := credentialProcessCredentials(st, args[0])
+		if err != nil {
+			return err
+		}
+		return writeCredentialProcess(os.Stdout, creds)
+	} else if wireCommand.Parsed() {
+		if len(args) != 1 {
+			return &usageError{"Required parameter <Profile> missing for wire command!"}
+		}
 
-		if profile.region == "" {
-			if defaultConfig.region != "" {
-				fmt.Printf("[%."+strconv.Itoa(regionLength-2)+"s", defaultConfig.region)
-				if len(defaultConfig.region) > regionLength-2 {
-					fmt.Printf("...")
-				}
-				fmt.Printf("] ")
-				fmt.Printf(fs(regionLength-len(defaultConfig.region)-3+4), "                  ")
-			} else {
-				fmt.Printf(fs(regionLength), profile.region)
-				truncPrintf(profile.region, regionLength)
-			}
-		} else {
-			fmt.Printf(fs(regionLength), profile.region)
-			truncPrintf(profile.region, regionLength)
+		err := wireProfile(st, args[0], *wireTo)
+		if err == nil {
+			err = st.Save()
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to wire Profile '%s': %w", args[0], err)
 		}
 
-		if profile.output == "" {
-			if defaultConfig.output != "" {
-				fmt.Printf("[%."+strconv.Itoa(outputLength-2)+"s", defaultConfig.output)
-				if len(defaultConfig.output) > outputLength-2 {
-					fmt.Printf("...")
-				}
-				fmt.Printf("] ")
-				fmt.Printf(fs(outputLength-len(defaultConfig.output)-3+4), "                  ")
+		fmt.Printf("Profile '%s' gets its credentials from '%s'\n", *wireTo, credentialProcessCommand(args[0]))
+	} else if ssoCommand.Parsed() {
+		if len(args) == 0 {
+			return &usageError{"Required parameter login or sync missing for sso command!"}
+		}
+
+		switch args[0] {
+		case "login":
+			if len(args) != 2 {
+				return &usageError{"Required parameter <Profile|Session> missing for sso login command!"}
+			}
+			login, err := st.SsoLogin(args[1])
+			if err != nil {
+				return err
+			}
+			token, err := ssoLogin(login, os.Stderr)
+			if err != nil {
+				return fmt.Errorf("Failed to log in to %s: %w", login.StartUrl, err)
+			}
+			fmt.Printf("Logged in to %s until %s\n", login.StartUrl, token.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+		case "sync":
+			sessionName := ""
+			if len(args) == 2 {
+				sessionName = args[1]
+			} else if sessions := st.SsoSessions(); len(sessions) == 1 {
+				sessionName = sessions[0]
 			} else {
-				fmt.Printf(fs(outputLength), profile.output)
-				truncPrintf(profile.output, outputLength)
+				return &usageError{"Required parameter <Session> missing for sso sync command!"}
 			}
-		} else {
-			fmt.Printf(fs(outputLength), profile.output)
-			truncPrintf(profile.output, outputLength)
+
+			created, err := ssoSync(st, sessionName, *ssoRegion)
+			if err == nil {
+				err = st.Save()
+			}
+			if err != nil {
+				return fmt.Errorf("Failed to sync the profiles of sso-session '%s': %w", sessionName, err)
+			}
+			for _, name := range created {
+				fmt.Printf("Added Profile '%s'\n", name)
+			}
+			fmt.Printf("Added %d Profile(s)\n\n", len(created))
+			return listProfiles(st)
+		default:
+			return &usageError{fmt.Sprintf("Unknown sso command '%s'!", args[0])}
+		}
+	} else if whoamiCommand.Parsed() {
+		profileName := whoamiProfileName()
+		if len(args) == 1 {
+			profileName = args[0]
+		}
+
+		identity, err := whoami(st, profileName)
+		if err != nil {
+			return fmt.Errorf("Failed to get the identity of Profile '%s': %w", profileName, err)
+		}
+		printIdentity(os.Stdout, profileName, identity)
+	} else if findCommand.Parsed() {
+		if !accountIdPattern.MatchString(*findAccount) {
+			return &usageError{"find requires --account <AccountId> with a 12 digit account id!"}
 		}
 
-		fmt.Printf("\n")
+		found := findProfilesByAccount(st, *findAccount)
+		if len(found) == 0 {
+			return fmt.Errorf("No Profile has an access key of account %s", *findAccount)
+		}
+		return printProfileTable(st, found, tableOptions{color: true})
+	} else if doctorCommand.Parsed() {
+		return doctor(st, os.Stdout, *doctorFix)
 	}
+	return nil
+} //run
 
-	if len(profiles) > 0 {
-		fmt.Printf("\n")
-		fmt.Println("Profiles with * are active profiles. Profiles with region or output in [] are using the default config.")
+func printUsage() {
+	fmt.Println("")
+	fmt.Println("Usage:")
+	fmt.Println("")
+	fmt.Printf("  %s [list] [--output table|json|yaml|csv]\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Lists all available profiles.")
+	fmt.Println("")
+	fmt.Println("      --sort name|region|active|last-used sorts the profiles, --region <Glob>, --name-glob <Glob>,")
+	fmt.Println("      --kind static|role|sso|process|web-identity and --active filter them.")
+	fmt.Println("      --columns name,kind,key,account,key-type,region,output,expiry,identity selects the columns of the table,")
+	fmt.Println("      --wide disables the truncation to the terminal width and --no-color the highlighting.")
+	fmt.Println("      --identity adds the IDENTITY column with the ARN whoami has cached for the access key of a Profile.")
+	fmt.Println("")
+	fmt.Printf("  %s activate <Profile>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Activates a given Profile. Role profiles are assumed and their temporary credentials")
+	fmt.Println("      are written to the default section unless --no-assume is given.")
+	fmt.Println("      --switch-config also copies region and output of the Profile into the default section of the")
+	fmt.Println("      config file. Set AWSENV_SWITCH_CONFIG=true to make this the default, --switch-config=false disables it.")
+	fmt.Println("")
+	fmt.Printf("  %s activate --export [--shell <Shell>] <Profile>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Activates a given Profile and prints shell statements exporting its variables,")
+	fmt.Println("      e.g. eval \"$(awsenv activate --export <Profile>)\".")
+	fmt.Println("")
+	fmt.Printf("  %s env [--shell <Shell>] <Profile>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Prints shell statements exporting the variables of a given Profile")
+	fmt.Println("      without activating it. <Shell> is one of bash, zsh, fish, powershell or cmd.")
+	fmt.Println("")
+	fmt.Printf("  %s use [--shell <Shell>] [--subshell] <Profile>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Selects a Profile only for the current shell by printing a statement exporting AWS_PROFILE,")
+	fmt.Println("      e.g. eval \"$(awsenv use <Profile>)\", or by starting a new shell with --subshell.")
+	fmt.Println("      The credentials file is not modified.")
+	fmt.Println("")
+	fmt.Printf("  %s exec [--override] <Profile> -- <Command> [<Args>...]\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Runs a command with the credentials and region of a Profile in its environment.")
+	fmt.Println("      Refuses to replace AWS_* variables which are already set unless --override is given.")
+	fmt.Println("")
+	fmt.Printf("  %s backups [list]\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Lists the backups of the default sections made by activate.")
+	fmt.Println("")
+	fmt.Printf("  %s backups restore <Timestamp|latest>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Restores a backup into the default sections.")
+	fmt.Println("")
+	fmt.Printf("  %s backups prune [--keep <N>] [--older-than <Age>]\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Deletes all but the newest N backups, or only the ones older than e.g. 30d.")
+	fmt.Println("")
+	fmt.Printf("  %s show [--output table|json] <Profile>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Shows all keys of a Profile with the file and line they are defined in, the keys inherited")
+	fmt.Println("      from the default config and the effective values after environment overrides. Secrets are masked.")
+	fmt.Println("")
+	fmt.Printf("  %s mfa [--duration <Seconds>] <Profile> <TokenCode>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Creates temporary credentials for a Profile using the code of its mfa_serial device")
+	fmt.Println("      and stores them in the Profile <Profile>-mfa.")
+	fmt.Println("")
+	fmt.Printf("  %s add [--access-key-id <Key> [--secret-access-key <Secret>]] [--role-arn <Arn> --source-profile <Profile>]\n", filepath.Base(os.Args[0]))
+	fmt.Println("      [--region <Region>] [--output <Output>] [--mfa-serial <Arn>] <Profile>")
+	fmt.Println("      Creates a Profile. Without --access-key-id and --role-arn the access key, secret, region and output")
+	fmt.Println("      are asked for like 'aws configure' does, or read line by line from stdin.")
+	fmt.Println("")
+	fmt.Printf("  %s rename <Profile> <NewProfile>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Renames a Profile in the credentials and config file and updates the source_profile references.")
+	fmt.Println("")
+	fmt.Printf("  %s copy <Profile> <NewProfile>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Copies a Profile in the credentials and config file.")
+	fmt.Println("")
+	fmt.Printf("  %s remove [--force] <Profile>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Removes a Profile from the credentials and config file. The active Profile is only removed with --force.")
+	fmt.Println("")
+	fmt.Printf("  %s rotate <Profile>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Creates a new access key for a static Profile, verifies it and deletes the old one.")
+	fmt.Println("      The default section is updated as well if the Profile is active.")
+	fmt.Println("")
+	fmt.Printf("  %s credential-process <Profile>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Prints the credentials of a static or role Profile in the json format of credential_process.")
+	fmt.Println("")
+	fmt.Printf("  %s wire [--to <Profile>] <Profile>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Sets credential_process = awsenv credential-process <Profile> in the config section of the")
+	fmt.Println("      --to Profile, default by default, whose keys are removed from the credentials file.")
+	fmt.Println("")
+	fmt.Printf("  %s sso login <Profile|Session>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Logs in to IAM Identity Center with the device authorization flow and writes the token")
+	fmt.Println("      to ~/.aws/sso/cache where the aws cli finds it.")
+	fmt.Println("")
+	fmt.Printf("  %s sso sync [--region <Region>] [<Session>]\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Creates a Profile for every account and role the login of the sso-session gives access to.")
+	fmt.Println("")
+	fmt.Printf("  %s whoami [<Profile>]\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Calls STS GetCallerIdentity with the credentials of a Profile, by default the one selected by")
+	fmt.Println("      AWS_PROFILE or default, and prints account, ARN and user ID. The result is cached for list --identity.")
+	fmt.Println("")
+	fmt.Printf("  %s find --account <AccountId>\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Lists the profiles whose access key belongs to an account. The account is decoded from the")
+	fmt.Println("      access key id without calling AWS, like the ACCOUNT column of list.")
+	fmt.Println("")
+	fmt.Printf("  %s doctor [--fix]\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Checks the credentials and config file for duplicate sections, keys without secret, malformed")
+	fmt.Println("      access key ids, profiles without credentials, source_profile cycles, secrets in the config file")
+	fmt.Println("      and file modes readable by other users. --fix merges duplicate sections, moves secrets into the")
+	fmt.Println("      credentials file and restricts the file modes. Exits with 1 if errors remain.")
+	fmt.Println("")
+	fmt.Printf("  %s help\n", filepath.Base(os.Args[0]))
+	fmt.Println("      Displays help information.")
+	fmt.Println("")
+	fmt.Println("  --verbose can be given with every command to print diagnostics to stderr.")
+	fmt.Println("")
+	fmt.Println("Exit codes: 0 success, 1 error, 2 invalid usage, 3 Profile not found, 4 file unreadable,")
+	fmt.Println("5 write failed, 6 default Profile not activatable. exec and use --subshell return the exit code of the command.")
+	fmt.Println("")
+	fmt.Println("Version: awsenv " + VERSION)
+} //printUsage
+
+//parseArgs parses the flags of a command and returns the remaining positional arguments.
+//Unlike flagSet.Parse it also accepts flags after positional arguments, e.g. activate prod --export
+func parseArgs(flagSet *flag.FlagSet, args []string) []string {
+	positional := make([]string, 0)
+	for {
+		_ = flagSet.Parse(args) //flagSet exits on error
+		args = flagSet.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+} //parseArgs
+
+//switchConfigDefault returns the default of the activate --switch-config flag which is read from
+//the AWSENV_SWITCH_CONFIG environment variable. Invalid values are ignored.
+func switchConfigDefault() bool {
+	switchConfig, err := strconv.ParseBool(os.Getenv("AWSENV_SWITCH_CONFIG"))
+	return err == nil && switchConfig
+} //switchConfigDefault
+
+//resolveShell validates the --shell flag and falls back to the detected shell if it is empty
+func resolveShell(shell string) (string, error) {
+	if shell == "" {
+		return detectShell(), nil
 	}
+	shell = strings.ToLower(shell)
+	if !isSupportedShell(shell) {
+		return "", &usageError{fmt.Sprintf("Unsupported shell '%s'! Supported shells are bash, zsh, fish, powershell and cmd.", shell)}
+	}
+	return shell, nil
+} //resolveShell
+
+//loadStore reads the credentials and config files used by the aws cli
+func loadStore() (*store.Store, error) {
+	credentialsPath, configPath := store.DefaultCredentialsPath(), store.DefaultConfigPath()
+	verbosef("Loading credentials file %s and config file %s", credentialsPath, configPath)
+	st, err := store.Load(credentialsPath, configPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, fileName := range st.MissingFiles() {
+		fmt.Fprintf(os.Stderr, "WARNING: %s does not exist and is treated as empty.\n", fileName)
+	}
+	return st, nil
+} //loadStore
+
+//listProfiles prints all profiles sorted by name
+func listProfiles(st *store.Store) error {
+	return printProfileTable(st, st.Profiles(), tableOptions{color: true})
 } //listProfiles
 
+//formatExpiration returns the local expiration time of temporary credentials
+func formatExpiration(expiration time.Time) string {
+	if expiration.IsZero() {
+		return ""
+	}
+	if time.Now().After(expiration) {
+		return "expired"
+	}
+	return expiration.Local().Format("2006-01-02 15:04")
+} //formatExpiration
+
 //format string pattern to eg %-10.10s
 func fs(l int) string {
 	//- for left justify
@@ -410,16 +743,6 @@ func fs(l int) string {
 	return "%-" + strconv.Itoa(l) + "." + strconv.Itoa(l) + "s"
 } //fs
 
-//TODO: rename func
-//truncate string longer than l and if longer pad with "... " otherwise pad with "    "
-func truncPrintf(s string, l int) {
-	if len(s) > l {
-		fmt.Printf("... ")
-	} else {
-		fmt.Printf("    ")
-	}
-} //truncPrintf
-
 func maskAccessKey(s string, l int) string {
 	var r string
 	if len(s) <= 4 {
@@ -442,47 +765,16 @@ func maskAccessKey(s string, l int) string {
 	return r
 } //maskAccessKey
 
-func setDefaultProfile(fromSectionName string) {
-	defaultSection := credentialsFile.Section(ini.DefaultSection)
-
-	//make a backup of the current default section so it doesn't get lost
-	//the default section is only active if there is no matching Profile present
-	if defaultProfile.isActive {
-		defaultBackupSectionName := "default-" + time.Now().Format("20060102150405")
-		_, _ = credentialsFile.NewSection(defaultBackupSectionName)
-		defaultBackupSection := credentialsFile.Section(defaultBackupSectionName)
-		for _, key := range defaultSection.Keys() {
-			keyName := key.Name()
-			value := key.Value()
-			_, _ = defaultBackupSection.NewKey(keyName, value)
+//setEnvironmentVariables only affects the awsenv process itself.
+//Use activate --export or env to change the environment of the calling shell.
+func setEnvironmentVariables(st *store.Store) {
+	if defaultProfile := st.DefaultProfile(); defaultProfile.AwsAccessKeyId != "" {
+		for _, v := range environmentVariables(st, defaultProfile) {
+			if v.value == "" {
+				os.Unsetenv(v.name)
+			} else {
+				os.Setenv(v.name, v.value)
+			}
 		}
 	}
-
-	for _, key := range defaultSection.Keys() {
-		keyName := key.Name()
-		defaultSection.DeleteKey(keyName)
-	}
-
-	fromSection := credentialsFile.Section(fromSectionName)
-	for _, key := range fromSection.Keys() {
-		keyName := key.Name()
-		value := key.Value()
-		_, _ = defaultSection.NewKey(keyName, value)
-	}
-
-	//TODO: handle error
-	_ = credentialsFile.SaveTo(getUser().HomeDir + "/.aws/credentials")
-
-	fmt.Printf("Activated Profile '%s'\n\n", fromSectionName)
-
-	parse()
-} //setDefaultProfile
-
-func setEnvironmentVariables() {
-	if defaultProfile.aws_access_key_id != "" {
-		os.Setenv("AWS_ACCESS_KEY_ID", defaultProfile.aws_access_key_id)
-		os.Setenv("AWS_SECRET_ACCESS_KEY", defaultProfile.aws_secret_access_key)
-		//TODO:
-		//set AWS_DEFAULT_REGION
-	}
 } //setEnvironmentVariables
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//listBackups prints the backups of the default and the mfa session sections from newest to oldest
+func listBackups(st *store.Store) {
+	nameLength := 22
+	createdLength := 19
+	awsAccessKeyIdLength := 20
+	regionLength := 14
+
+	fmt.Printf(fs(nameLength), "BACKUP")
+	fmt.Printf("    ")
+	fmt.Printf(fs(createdLength), "CREATED")
+	fmt.Printf("    ")
+	fmt.Printf(fs(awsAccessKeyIdLength), "AWS_ACCESS_KEY_ID")
+	fmt.Printf("    ")
+	fmt.Printf(fs(regionLength), "REGION")
+	fmt.Printf("    ")
+	fmt.Printf("OUTPUT")
+	fmt.Printf("\n")
+
+	backups := st.Backups()
+	for _, backup := range backups {
+		fmt.Printf(fs(nameLength), backup.SectionName)
+		fmt.Printf("    ")
+		fmt.Printf(fs(createdLength), backup.Timestamp.Format("2006-01-02 15:04:05"))
+		fmt.Printf("    ")
+		fmt.Printf(fs(awsAccessKeyIdLength), maskAccessKey(backup.AwsAccessKeyId, awsAccessKeyIdLength))
+		fmt.Printf("    ")
+		fmt.Printf(fs(regionLength), backup.Region)
+		fmt.Printf("    ")
+		fmt.Printf("%s", backup.Output)
+		fmt.Printf("\n")
+	}
+
+	if len(backups) == 0 {
+		fmt.Println("No backups found.")
+	}
+} //listBackups
+
+//parseAge parses durations like 30d in addition to the units supported by time.ParseDuration
+func parseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid age '%s'", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age '%s'", s)
+	}
+	return age, nil
+} //parseAge
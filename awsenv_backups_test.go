@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	ages := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+		"0d":  0,
+	}
+	for s, expected := range ages {
+		if age, err := parseAge(s); err != nil || age != expected {
+			t.Errorf("TestParseAge: '%s' is not parsed as %v: %v %v", s, expected, age, err)
+			t.Fail()
+		}
+	}
+
+	for _, s := range []string{"d", "-1d", "30x", ""} {
+		if _, err := parseAge(s); err == nil {
+			t.Errorf("TestParseAge: invalid age '%s' has been accepted", s)
+			t.Fail()
+		}
+	}
+} //TestParseAge
+
+func TestPruneBackupsCommand(t *testing.T) {
+	tests := []struct {
+		args      []string
+		remaining int
+	}{
+		{[]string{"backups", "prune", "--keep", "0"}, 0},
+		{[]string{"backups", "prune", "--older-than", "0d"}, 0},
+		{[]string{"backups", "prune", "--keep", "1", "--older-than", "0d"}, 1},
+	}
+	for _, test := range tests {
+		os.Setenv("AWS_SHARED_CREDENTIALS_FILE", copyTestdata(t, "backups_credentials", 0600))
+		os.Setenv("AWS_CONFIG_FILE", "./testdata/empty_file")
+
+		captureStdout(t, func() {
+			if err := run(test.args); err != nil {
+				t.Errorf("TestPruneBackupsCommand: %v failed: %v", test.args, err)
+				t.Fail()
+			}
+		})
+		if backups := loadTestStore(t).Backups(); len(backups) != test.remaining {
+			t.Errorf("TestPruneBackupsCommand: %v did not keep %d backup(s): %v", test.args, test.remaining, backups)
+			t.Fail()
+		}
+	}
+
+	if code := exitCode(run([]string{"backups", "prune"})); code != exitUsage {
+		t.Errorf("TestPruneBackupsCommand: prune without --keep and --older-than didn't exit with %d: %d", exitUsage, code)
+		t.Fail()
+	}
+} //TestPruneBackupsCommand
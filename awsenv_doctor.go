@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//doctor reports the findings of linting the credentials and config file. With fix the safe findings are
+//fixed and saved first, and only the remaining ones are reported. An error is returned if findings of
+//severity error remain, so doctor can be used as check in scripts.
+func doctor(st *store.Store, w io.Writer, fix bool) error {
+	findings, err := st.Lint()
+	if err != nil {
+		return err
+	}
+
+	if fix {
+		fixed, err := st.Fix(findings)
+		if err == nil {
+			err = st.Save()
+		}
+		for _, finding := range fixed {
+			fmt.Fprintf(w, "Fixed %s\n", formatFinding(finding))
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to fix the findings: %w", err)
+		}
+		if len(fixed) > 0 {
+			fmt.Fprintln(w, "")
+		}
+		if findings, err = st.Lint(); err != nil {
+			return err
+		}
+	}
+
+	errorCount, fixableCount := 0, 0
+	for _, finding := range findings {
+		line := formatFinding(finding)
+		if finding.Fixable() {
+			line += " (fixable)"
+			fixableCount++
+		}
+		if finding.Severity == store.SeverityError {
+			errorCount++
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "No problems found.")
+		return nil
+	}
+	fmt.Fprintf(w, "\nFound %d error(s) and %d warning(s).", errorCount, len(findings)-errorCount)
+	if fixableCount > 0 {
+		fmt.Fprintf(w, " Run '%s doctor --fix' to fix %d of them.", filepath.Base(os.Args[0]), fixableCount)
+	}
+	fmt.Fprintln(w, "")
+
+	if errorCount > 0 {
+		return fmt.Errorf("doctor found %d error(s)", errorCount)
+	}
+	return nil
+} //doctor
+
+//formatFinding returns a finding as e.g. ERROR   credentials:12 [prod] missing-secret: <message>
+func formatFinding(finding store.Finding) string {
+	location := filepath.Base(finding.Path)
+	if finding.Line > 0 {
+		location += ":" + strconv.Itoa(finding.Line)
+	}
+	if finding.Section != "" {
+		location += " [" + finding.Section + "]"
+	}
+	return fmt.Sprintf("%-7s %s %s: %s", strings.ToUpper(finding.Severity), location, finding.Check, finding.Message)
+} //formatFinding
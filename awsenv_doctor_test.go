@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+//setupDoctorTest copies the files with findings into a temporary directory as doctor --fix saves them
+func setupDoctorTest(t *testing.T) {
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", copyTestdata(t, "doctor_credentials", 0600))
+	os.Setenv("AWS_CONFIG_FILE", copyTestdata(t, "doctor_config", 0600))
+} //setupDoctorTest
+
+func TestDoctor(t *testing.T) {
+	setupDoctorTest(t)
+	st := loadTestStore(t)
+
+	var buf bytes.Buffer
+	if err := doctor(st, &buf, false); err == nil || exitCode(err) != exitError {
+		t.Errorf("TestDoctor: errors should fail with exit code %d: %v", exitError, err)
+		t.Fail()
+	}
+	if !strings.Contains(buf.String(), "ERROR   doctor_credentials:31 [session] duplicate-section: ") || !strings.Contains(buf.String(), "(fixable)") {
+		t.Errorf("TestDoctor: unexpected output:\n%s", buf.String())
+		t.Fail()
+	}
+	if !strings.Contains(buf.String(), "Found 7 error(s) and 4 warning(s). Run '") {
+		t.Errorf("TestDoctor: unexpected summary:\n%s", buf.String())
+		t.Fail()
+	}
+
+	buf.Reset()
+	if err := doctor(st, &buf, true); err == nil {
+		t.Errorf("TestDoctor: errors which can't be fixed should still fail")
+		t.Fail()
+	}
+	if !strings.Contains(buf.String(), "Fixed ERROR   doctor_credentials:31 [session] duplicate-section: ") || strings.Contains(buf.String(), "(fixable)") {
+		t.Errorf("TestDoctor: unexpected output of --fix:\n%s", buf.String())
+		t.Fail()
+	}
+} //TestDoctor
+
+func TestDoctorNoProblems(t *testing.T) {
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/does_not_exist")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/does_not_exist")
+	st := loadTestStore(t)
+
+	var buf bytes.Buffer
+	if err := doctor(st, &buf, false); err != nil || buf.String() != "No problems found.\n" {
+		t.Errorf("TestDoctorNoProblems: unexpected result %v:\n%s", err, buf.String())
+		t.Fail()
+	}
+} //TestDoctorNoProblems
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//exit codes which allow wrapper scripts to distinguish why awsenv failed
+const (
+	exitOk                    = 0
+	exitError                 = 1
+	exitUsage                 = 2
+	exitProfileNotFound       = 3
+	exitFileUnreadable        = 4
+	exitWriteFailed           = 5
+	exitDefaultNotActivatable = 6
+)
+
+//verbose is set by --verbose and enables diagnostics on stderr
+var verbose bool
+
+//usageError is returned for invalid command lines, the usage is printed after the message
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+//exitStatusError passes on the exit code of a command run by exec or use --subshell
+type exitStatusError struct {
+	code int
+}
+
+func (e *exitStatusError) Error() string {
+	return fmt.Sprintf("command exited with status %d", e.code)
+}
+
+//exitCode maps an error returned by run to the documented exit codes
+func exitCode(err error) int {
+	var exitStatus *exitStatusError
+	var usage *usageError
+	switch {
+	case err == nil:
+		return exitOk
+	case errors.As(err, &exitStatus):
+		return exitStatus.code
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, store.ErrProfileNotFound):
+		return exitProfileNotFound
+	case errors.Is(err, store.ErrFileUnreadable):
+		return exitFileUnreadable
+	case errors.Is(err, store.ErrWriteFailed):
+		return exitWriteFailed
+	case errors.Is(err, store.ErrDefaultNotActivatable):
+		return exitDefaultNotActivatable
+	}
+	return exitError
+} //exitCode
+
+//reportError prints an error returned by run to stderr. With --verbose the chain of underlying
+//errors and the exit code are printed as well.
+func reportError(err error) {
+	var exitStatus *exitStatusError
+	if err == nil || errors.As(err, &exitStatus) {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+	if errors.Is(err, store.ErrProfileNotFound) {
+		fmt.Fprintf(os.Stderr, "Run '%s list' to see the available profiles.\n", filepath.Base(os.Args[0]))
+	}
+
+	if verbose {
+		for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+			fmt.Fprintf(os.Stderr, "  caused by %T: %v\n", cause, cause)
+		}
+		fmt.Fprintf(os.Stderr, "  exit code %d\n", exitCode(err))
+	}
+
+	var usage *usageError
+	if errors.As(err, &usage) {
+		printUsage()
+	}
+} //reportError
+
+//verbosef prints a diagnostic message to stderr if --verbose is given
+func verbosef(format string, a ...interface{}) {
+	if verbose {
+		fmt.Fprintf(os.Stderr, "VERBOSE: "+format+"\n", a...)
+	}
+} //verbosef
+
+//extractVerbose removes --verbose from the arguments so it can be given with every command.
+//Arguments after -- or after the Profile of exec belong to the command run by exec and are kept as they are.
+func extractVerbose(args []string) ([]string, bool) {
+	remaining := make([]string, 0, len(args))
+	found := false
+	for i, arg := range args {
+		if arg == "--" {
+			remaining = append(remaining, args[i:]...)
+			break
+		}
+		if arg == "--verbose" || arg == "-verbose" {
+			found = true
+			continue
+		}
+		remaining = append(remaining, arg)
+		//the flags of exec have no values, so the first other argument after exec is the Profile
+		if len(remaining) > 1 && remaining[0] == "exec" && !strings.HasPrefix(arg, "-") {
+			remaining = append(remaining, args[i+1:]...)
+			break
+		}
+	}
+	return remaining, found
+} //extractVerbose
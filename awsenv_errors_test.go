@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{nil, exitOk},
+		{errors.New("STS failed"), exitError},
+		{&usageError{"Too many arguments supplied."}, exitUsage},
+		{&exitStatusError{42}, 42},
+		{&store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: "missing"}, exitProfileNotFound},
+		{&store.FileError{Kind: store.ErrFileUnreadable, Path: "credentials", Err: os.ErrNotExist}, exitFileUnreadable},
+		{&store.FileError{Kind: store.ErrWriteFailed, Path: "credentials", Err: os.ErrPermission}, exitWriteFailed},
+		{&store.ProfileError{Kind: store.ErrDefaultNotActivatable, ProfileName: "default"}, exitDefaultNotActivatable},
+		//wrapped errors keep their exit code
+		{fmt.Errorf("Failed to activate Profile 'prod': %w", &store.FileError{Kind: store.ErrWriteFailed, Path: "credentials", Err: os.ErrPermission}), exitWriteFailed},
+		{fmt.Errorf("Failed to assume role of Profile 'admin': %w", &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: "source"}), exitProfileNotFound},
+	}
+
+	for _, test := range tests {
+		if actual := exitCode(test.err); actual != test.expected {
+			t.Errorf("TestExitCode: exit code of '%v' is not %d: %d", test.err, test.expected, actual)
+			t.Fail()
+		}
+	}
+} //TestExitCode
+
+func TestLoadStoreErrors(t *testing.T) {
+	//a directory can't be read as credentials file
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/only_default_config")
+
+	_, err := loadStore()
+	if exitCode(err) != exitFileUnreadable {
+		t.Errorf("TestLoadStoreErrors: exit code of an unreadable credentials file is not %d: %v", exitFileUnreadable, err)
+		t.Fail()
+	}
+
+	if errors.Unwrap(err) == nil {
+		t.Errorf("TestLoadStoreErrors: the underlying error is not available with --verbose: %v", err)
+		t.Fail()
+	}
+} //TestLoadStoreErrors
+
+func TestExtractVerbose(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []string
+		verbose  bool
+	}{
+		{[]string{}, []string{}, false},
+		{[]string{"activate", "prod"}, []string{"activate", "prod"}, false},
+		{[]string{"--verbose", "activate", "prod"}, []string{"activate", "prod"}, true},
+		{[]string{"activate", "prod", "-verbose"}, []string{"activate", "prod"}, true},
+		//arguments of the command run by exec are not touched
+		{[]string{"exec", "prod", "--", "terraform", "--verbose"}, []string{"exec", "prod", "--", "terraform", "--verbose"}, false},
+		{[]string{"exec", "prod", "terraform", "--verbose"}, []string{"exec", "prod", "terraform", "--verbose"}, false},
+		{[]string{"--verbose", "exec", "--override", "prod", "terraform", "plan", "--verbose"}, []string{"exec", "--override", "prod", "terraform", "plan", "--verbose"}, true},
+		{[]string{"exec", "--verbose", "prod", "terraform"}, []string{"exec", "prod", "terraform"}, true},
+	}
+
+	for _, test := range tests {
+		args, verbose := extractVerbose(test.args)
+		if !reflect.DeepEqual(args, test.expected) || verbose != test.verbose {
+			t.Errorf("TestExtractVerbose: %v is not split into %v %v: %v %v", test.args, test.expected, test.verbose, args, verbose)
+			t.Fail()
+		}
+	}
+} //TestExtractVerbose
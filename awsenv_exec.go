@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+//splitCommandArgs splits the arguments of the exec command into the awsenv part and the command to run.
+//The command either follows a -- or the first positional argument, i.e. the Profile.
+func splitCommandArgs(flagSet *flag.FlagSet, args []string) ([]string, []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return parseArgs(flagSet, args[:i]), args[i+1:]
+		}
+	}
+
+	_ = flagSet.Parse(args) //flagSet exits on error
+	remaining := flagSet.Args()
+	if len(remaining) == 0 {
+		return remaining, remaining
+	}
+	return remaining[:1], remaining[1:]
+} //splitCommandArgs
+
+//execVariables returns the variables injected into the environment of the command. Static keys, and the
+//temporary keys of role profiles, are passed directly, sso, credential_process and web identity profiles by name only.
+func execVariables(st *store.Store, profile store.Profile) ([]envVar, error) {
+	switch profile.Kind {
+	case store.KindRole:
+		creds, err := resolveCredentials(st, profile.Name)
+		if err != nil {
+			return nil, err
+		}
+		profile = store.WithCredentials(profile, creds)
+	case store.KindSso, store.KindProcess, store.KindWebIdentity:
+		if profile.AwsAccessKeyId == "" {
+			vars := shellProfileVariables(profile)
+			region := effectiveRegion(st, profile)
+			for i := range vars {
+				if vars[i].name == "AWS_DEFAULT_REGION" || vars[i].name == "AWS_REGION" {
+					vars[i].value = region
+				}
+			}
+			return vars, nil
+		}
+	}
+	return environmentVariables(st, profile), nil
+} //execVariables
+
+//conflictingVariables returns the AWS_* variables set in the environment of awsenv which would be replaced
+//with a different value for the command
+func conflictingVariables(vars []envVar) []string {
+	conflicts := make([]string, 0)
+	for _, v := range vars {
+		if current, ok := os.LookupEnv(v.name); ok && current != "" && current != v.value {
+			conflicts = append(conflicts, v.name)
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts
+} //conflictingVariables
+
+//execProfile runs a command with the credentials of a Profile. A non-zero exit code of the command
+//is returned as exitStatusError.
+func execProfile(st *store.Store, profile store.Profile, command []string, override bool) error {
+	vars, err := execVariables(st, profile)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve credentials of Profile '%s': %w", profile.Name, err)
+	}
+
+	if conflicts := conflictingVariables(vars); len(conflicts) > 0 && !override {
+		return fmt.Errorf("The environment already sets %s. Use --override to replace them for the command.",
+			strings.Join(conflicts, ", "))
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = childEnvironment(vars)
+	return runChild(cmd)
+} //execProfile
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//TestExecHelperProcess is not a real test but the command run by execProfile in the tests below
+func TestExecHelperProcess(t *testing.T) {
+	outputFileName := os.Getenv("AWSENV_TEST_EXEC_OUTPUT")
+	if outputFileName == "" {
+		return
+	}
+	content := fmt.Sprintf("%s|%s|%s|%s|%s",
+		os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN"),
+		os.Getenv("AWS_REGION"), os.Getenv("AWS_PROFILE"))
+	_ = ioutil.WriteFile(outputFileName, []byte(content), 0600)
+	os.Exit(3)
+} //TestExecHelperProcess
+
+func setupExecTest(t *testing.T) (*store.Store, string) {
+	outputFileName := filepath.Join(t.TempDir(), "output")
+	os.Setenv("AWSENV_TEST_EXEC_OUTPUT", outputFileName)
+	t.Cleanup(func() {
+		os.Unsetenv("AWSENV_TEST_EXEC_OUTPUT")
+		os.Unsetenv("AWS_ACCESS_KEY_ID")
+	})
+
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/profile_sections_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/profile_sections_config")
+	return loadTestStore(t), outputFileName
+} //setupExecTest
+
+func TestExecProfile(t *testing.T) {
+	st, outputFileName := setupExecTest(t)
+
+	err := execProfile(st, testProfile(t, st, "dev"), []string{os.Args[0], "-test.run=TestExecHelperProcess"}, false)
+
+	if exitCode(err) != 3 {
+		t.Errorf("TestExecProfile: exit code of the command is not 3: %v", err)
+		t.Fail()
+	}
+
+	content, _ := ioutil.ReadFile(outputFileName)
+	expected := "12345678901234567891|1234567890123456789012345678901234567891||us-west-2|dev"
+	if string(content) != expected {
+		t.Errorf("TestExecProfile: environment of the command is not '%s': %s", expected, content)
+		t.Fail()
+	}
+
+	if os.Getenv("AWS_PROFILE") != "" {
+		t.Errorf("TestExecProfile: environment of awsenv has been modified")
+		t.Fail()
+	}
+} //TestExecProfile
+
+func TestExecProfileConflict(t *testing.T) {
+	st, outputFileName := setupExecTest(t)
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIDPINNED")
+
+	err := execProfile(st, testProfile(t, st, "dev"), []string{os.Args[0], "-test.run=TestExecHelperProcess"}, false)
+
+	if exitCode(err) != exitError {
+		t.Errorf("TestExecProfileConflict: exit code is not %d: %v", exitError, err)
+		t.Fail()
+	}
+
+	if _, err := os.Stat(outputFileName); err == nil {
+		t.Errorf("TestExecProfileConflict: command has been run despite the conflicting AWS_ACCESS_KEY_ID")
+		t.Fail()
+	}
+
+	err = execProfile(st, testProfile(t, st, "dev"), []string{os.Args[0], "-test.run=TestExecHelperProcess"}, true)
+	content, _ := ioutil.ReadFile(outputFileName)
+
+	if exitCode(err) != 3 || !strings.HasPrefix(string(content), "12345678901234567891|") {
+		t.Errorf("TestExecProfileConflict: --override did not replace AWS_ACCESS_KEY_ID: %v %s", err, content)
+		t.Fail()
+	}
+} //TestExecProfileConflict
+
+func TestSplitCommandArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		profile  []string
+		command  []string
+		override bool
+	}{
+		{[]string{"prod", "--", "aws", "s3", "ls"}, []string{"prod"}, []string{"aws", "s3", "ls"}, false},
+		{[]string{"prod", "--override", "--", "terraform", "plan", "-out", "x"}, []string{"prod"}, []string{"terraform", "plan", "-out", "x"}, true},
+		{[]string{"--override", "prod", "aws", "--version"}, []string{"prod"}, []string{"aws", "--version"}, true},
+		{[]string{"prod"}, []string{"prod"}, []string{}, false},
+	}
+
+	for _, test := range tests {
+		flagSet := flag.NewFlagSet("exec", flag.ContinueOnError)
+		override := flagSet.Bool("override", false, "")
+
+		profile, command := splitCommandArgs(flagSet, test.args)
+
+		if strings.Join(profile, " ") != strings.Join(test.profile, " ") ||
+			strings.Join(command, " ") != strings.Join(test.command, " ") ||
+			*override != test.override {
+			t.Errorf("TestSplitCommandArgs: %v is not split into %v %v %t: %v %v %t",
+				test.args, test.profile, test.command, test.override, profile, command, *override)
+			t.Fail()
+		}
+	}
+} //TestSplitCommandArgs
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+//supported values for the --shell flag
+const (
+	shellBash       = "bash"
+	shellZsh        = "zsh"
+	shellFish       = "fish"
+	shellPowerShell = "powershell"
+	shellCmd        = "cmd"
+)
+
+type envVar struct {
+	name  string
+	value string
+}
+
+//environmentVariables returns the AWS_* variables for the given Profile in a fixed order.
+//Variables with an empty value are meant to be unset so that values of a previously
+//exported Profile don't leak into the new one.
+func environmentVariables(st *store.Store, profile store.Profile) []envVar {
+	region := effectiveRegion(st, profile)
+
+	return []envVar{
+		{"AWS_ACCESS_KEY_ID", profile.AwsAccessKeyId},
+		{"AWS_SECRET_ACCESS_KEY", profile.AwsSecretAccessKey},
+		{"AWS_SESSION_TOKEN", profile.AwsSessionToken},
+		{"AWS_DEFAULT_REGION", region},
+		{"AWS_REGION", region},
+		{"AWS_PROFILE", profile.Name},
+	}
+} //environmentVariables
+
+//detectShell guesses the shell of the parent process if no --shell flag was given
+func detectShell() string {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	switch shell {
+	case shellZsh, shellFish, shellBash:
+		return shell
+	}
+	if runtime.GOOS == "windows" {
+		if os.Getenv("PSModulePath") != "" {
+			return shellPowerShell
+		}
+		return shellCmd
+	}
+	return shellBash
+} //detectShell
+
+func isSupportedShell(shell string) bool {
+	switch shell {
+	case shellBash, shellZsh, shellFish, shellPowerShell, shellCmd:
+		return true
+	}
+	return false
+} //isSupportedShell
+
+//formatExports renders the variables as statements that can be evaluated by the given shell,
+//e.g. eval "$(awsenv env prod)" for bash/zsh or awsenv env prod | source for fish
+func formatExports(shell string, vars []envVar) string {
+	var sb strings.Builder
+	for _, v := range vars {
+		switch shell {
+		case shellFish:
+			if v.value == "" {
+				fmt.Fprintf(&sb, "set -e %s;\n", v.name)
+			} else {
+				fmt.Fprintf(&sb, "set -gx %s %s;\n", v.name, quoteFish(v.value))
+			}
+		case shellPowerShell:
+			if v.value == "" {
+				fmt.Fprintf(&sb, "Remove-Item Env:%s -ErrorAction SilentlyContinue\n", v.name)
+			} else {
+				fmt.Fprintf(&sb, "$Env:%s = '%s'\n", v.name, strings.ReplaceAll(v.value, "'", "''"))
+			}
+		case shellCmd:
+			fmt.Fprintf(&sb, "set \"%s=%s\"\n", v.name, v.value)
+		default:
+			if v.value == "" {
+				fmt.Fprintf(&sb, "unset %s\n", v.name)
+			} else {
+				fmt.Fprintf(&sb, "export %s='%s'\n", v.name, strings.ReplaceAll(v.value, "'", `'\''`))
+			}
+		}
+	}
+	return sb.String()
+} //formatExports
+
+func quoteFish(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "'", `\'`)
+	return "'" + s + "'"
+} //quoteFish
+
+//printExports writes the export statements for the given Profile to stdout.
+//Everything else awsenv prints in export mode has to go to stderr so the output can be evaluated.
+func printExports(st *store.Store, shell string, profile store.Profile) {
+	fmt.Print(formatExports(shell, environmentVariables(st, profile)))
+} //printExports
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnvironmentVariables(t *testing.T) {
+
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/one_profile_matching_default_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/only_default_config")
+	st := loadTestStore(t)
+
+	vars := environmentVariables(st, testProfile(t, st, "profile_matching_default_credentials"))
+
+	expected := []envVar{
+		{"AWS_ACCESS_KEY_ID", "12345678901234567890"},
+		{"AWS_SECRET_ACCESS_KEY", "1234567890123456789012345678901234567890"},
+		{"AWS_SESSION_TOKEN", ""},
+		{"AWS_DEFAULT_REGION", "us-east-1"},
+		{"AWS_REGION", "us-east-1"},
+		{"AWS_PROFILE", "profile_matching_default_credentials"},
+	}
+
+	if len(vars) != len(expected) {
+		t.Fatalf("TestEnvironmentVariables: len(vars) is not %d: %d", len(expected), len(vars))
+	}
+	for i, v := range vars {
+		if v != expected[i] {
+			t.Errorf("TestEnvironmentVariables: variable %d is not %v: %v ", i, expected[i], v)
+			t.Fail()
+		}
+	}
+} //TestEnvironmentVariables
+
+func TestFormatExports(t *testing.T) {
+	vars := []envVar{
+		{"AWS_ACCESS_KEY_ID", "AKIDEXAMPLE"},
+		{"AWS_SECRET_ACCESS_KEY", "it's\\secret"},
+		{"AWS_SESSION_TOKEN", ""},
+	}
+
+	expected := map[string]string{
+		shellBash: "export AWS_ACCESS_KEY_ID='AKIDEXAMPLE'\n" +
+			"export AWS_SECRET_ACCESS_KEY='it'\\''s\\secret'\n" +
+			"unset AWS_SESSION_TOKEN\n",
+		shellZsh: "export AWS_ACCESS_KEY_ID='AKIDEXAMPLE'\n" +
+			"export AWS_SECRET_ACCESS_KEY='it'\\''s\\secret'\n" +
+			"unset AWS_SESSION_TOKEN\n",
+		shellFish: "set -gx AWS_ACCESS_KEY_ID 'AKIDEXAMPLE';\n" +
+			"set -gx AWS_SECRET_ACCESS_KEY 'it\\'s\\\\secret';\n" +
+			"set -e AWS_SESSION_TOKEN;\n",
+		shellPowerShell: "$Env:AWS_ACCESS_KEY_ID = 'AKIDEXAMPLE'\n" +
+			"$Env:AWS_SECRET_ACCESS_KEY = 'it''s\\secret'\n" +
+			"Remove-Item Env:AWS_SESSION_TOKEN -ErrorAction SilentlyContinue\n",
+		shellCmd: "set \"AWS_ACCESS_KEY_ID=AKIDEXAMPLE\"\n" +
+			"set \"AWS_SECRET_ACCESS_KEY=it's\\secret\"\n" +
+			"set \"AWS_SESSION_TOKEN=\"\n",
+	}
+
+	for shell, want := range expected {
+		if got := formatExports(shell, vars); got != want {
+			t.Errorf("TestFormatExports: %s output is not\n%s\nactual:\n%s", shell, want, got)
+			t.Fail()
+		}
+	}
+} //TestFormatExports
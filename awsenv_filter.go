@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+//supported values for the --sort flag of the list command
+const (
+	sortByName     = "name"
+	sortByRegion   = "region"
+	sortByActive   = "active"
+	sortByLastUsed = "last-used"
+)
+
+type listOptions struct {
+	sortBy     string
+	region     string
+	nameGlob   string
+	kind       string
+	activeOnly bool
+}
+
+//method pointer which can be changed during test case execution
+var getLastUsedFilePath = func() string {
+	return filepath.Join(filepath.Dir(store.DefaultConfigPath()), "awsenv_last_used.json")
+}
+
+//selectProfiles applies the filters of the list command to the profiles and sorts them.
+//Globs use the syntax of path.Match, e.g. eu-* or prod-?
+func selectProfiles(st *store.Store, options listOptions) ([]store.Profile, error) {
+	for _, pattern := range []string{options.region, options.nameGlob} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", pattern, err)
+		}
+	}
+
+	switch options.kind {
+	case "", store.KindStatic, store.KindRole, store.KindSso, store.KindProcess, store.KindWebIdentity:
+	default:
+		return nil, &usageError{fmt.Sprintf("Unsupported kind '%s'! Supported kinds are static, role, sso, process and web-identity.", options.kind)}
+	}
+
+	selected := make([]store.Profile, 0)
+	for _, profile := range st.Profiles() {
+		if options.nameGlob != "" {
+			if ok, _ := path.Match(options.nameGlob, profile.Name); !ok {
+				continue
+			}
+		}
+		if options.region != "" {
+			if ok, _ := path.Match(options.region, effectiveRegion(st, profile)); !ok {
+				continue
+			}
+		}
+		if options.kind != "" && profile.Kind != options.kind {
+			continue
+		}
+		if options.activeOnly && !profile.IsActive && !profile.IsShellActive {
+			continue
+		}
+		selected = append(selected, profile)
+	}
+
+	var less func(a store.Profile, b store.Profile) bool
+	switch options.sortBy {
+	case sortByName, "":
+		less = func(a store.Profile, b store.Profile) bool { return false }
+	case sortByRegion:
+		less = func(a store.Profile, b store.Profile) bool { return effectiveRegion(st, a) < effectiveRegion(st, b) }
+	case sortByActive:
+		less = func(a store.Profile, b store.Profile) bool {
+			return (a.IsActive || a.IsShellActive) && !(b.IsActive || b.IsShellActive)
+		}
+	case sortByLastUsed:
+		lastUsed := readLastUsed()
+		less = func(a store.Profile, b store.Profile) bool { return lastUsed[a.Name].After(lastUsed[b.Name]) }
+	default:
+		return nil, &usageError{fmt.Sprintf("Unsupported sort '%s'! Supported sorts are name, region, active and last-used.", options.sortBy)}
+	}
+
+	//ties are always ordered by name so the output is deterministic
+	sort.Slice(selected, func(i, j int) bool {
+		if less(selected[i], selected[j]) {
+			return true
+		}
+		if less(selected[j], selected[i]) {
+			return false
+		}
+		return selected[i].Name < selected[j].Name
+	})
+	return selected, nil
+} //selectProfiles
+
+//account ids are always 12 digits including leading zeros
+var accountIdPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+//findProfilesByAccount returns the profiles whose access key id decodes to the account, sorted by name
+func findProfilesByAccount(st *store.Store, account string) []store.Profile {
+	found := make([]store.Profile, 0)
+	for _, profile := range st.Profiles() {
+		if keyAccount, ok := store.AccessKeyAccount(profile.AwsAccessKeyId); ok && keyAccount == account {
+			found = append(found, profile)
+		}
+	}
+	return found
+} //findProfilesByAccount
+
+//effectiveRegion returns the region of the Profile or of the default config
+func effectiveRegion(st *store.Store, profile store.Profile) string {
+	if profile.Region != "" {
+		return profile.Region
+	}
+	return st.DefaultConfig().Region
+} //effectiveRegion
+
+func readLastUsed() map[string]time.Time {
+	lastUsed := make(map[string]time.Time)
+	content, err := ioutil.ReadFile(getLastUsedFilePath())
+	if err == nil {
+		_ = json.Unmarshal(content, &lastUsed)
+	}
+	return lastUsed
+} //readLastUsed
+
+//markLastUsed records when a Profile has been used by activate, use, env or exec for list --sort last-used.
+//Failures are only reported with --verbose as this is only a convenience.
+func markLastUsed(profileName string) {
+	lastUsed := readLastUsed()
+	lastUsed[profileName] = time.Now().UTC().Truncate(time.Second)
+	content, err := json.MarshalIndent(lastUsed, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(filepath.Dir(getLastUsedFilePath())); err != nil {
+		return
+	}
+	if err := store.WriteFileAtomic(getLastUsedFilePath(), content); err != nil {
+		verbosef("Failed to record the last use of Profile '%s': %v", profileName, err)
+	}
+} //markLastUsed
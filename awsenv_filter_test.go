@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"github.com/BernhardLenz/awsenv/store"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func profileNames(profiles []store.Profile) []string {
+	names := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+	}
+	return names
+} //profileNames
+
+func TestSelectProfiles(t *testing.T) {
+
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/config_only_profiles_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/config_only_profiles_config")
+	st := loadTestStore(t)
+
+	tests := []struct {
+		options  listOptions
+		expected []string
+	}{
+		{listOptions{}, []string{"admin", "prod", "sso-dev", "vault"}},
+		{listOptions{region: "eu-*"}, []string{"prod", "sso-dev"}},
+		{listOptions{region: "us-east-1"}, []string{"admin", "vault"}},
+		{listOptions{nameGlob: "*-*"}, []string{"sso-dev"}},
+		{listOptions{kind: "role"}, []string{"admin"}},
+		{listOptions{kind: "sso", region: "eu-*"}, []string{"sso-dev"}},
+		{listOptions{sortBy: sortByRegion}, []string{"prod", "sso-dev", "admin", "vault"}},
+	}
+
+	for _, test := range tests {
+		selected, err := selectProfiles(st, test.options)
+		if err != nil {
+			t.Errorf("TestSelectProfiles: %+v failed: %v", test.options, err)
+			t.Fail()
+			continue
+		}
+		if names := profileNames(selected); !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("TestSelectProfiles: %+v is not %v: %v", test.options, test.expected, names)
+			t.Fail()
+		}
+	}
+
+	var usage *usageError
+	if _, err := selectProfiles(st, listOptions{sortBy: "size"}); !errors.As(err, &usage) {
+		t.Errorf("TestSelectProfiles: an unsupported sort didn't fail with a usageError: %v", err)
+		t.Fail()
+	}
+	if _, err := selectProfiles(st, listOptions{kind: "bogus"}); !errors.As(err, &usage) {
+		t.Errorf("TestSelectProfiles: an unsupported kind didn't fail with a usageError: %v", err)
+		t.Fail()
+	}
+	if code := exitCode(run([]string{"list", "--kind", "bogus"})); code != exitUsage {
+		t.Errorf("TestSelectProfiles: list --kind bogus didn't exit with %d: %d", exitUsage, code)
+		t.Fail()
+	}
+	if _, err := selectProfiles(st, listOptions{nameGlob: "prod-["}); err == nil {
+		t.Errorf("TestSelectProfiles: an invalid glob didn't fail")
+		t.Fail()
+	}
+} //TestSelectProfiles
+
+func TestSelectActiveProfiles(t *testing.T) {
+
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/two_profiles_matching_default_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/only_default_config")
+	os.Setenv("AWS_PROFILE", "")
+	st := loadTestStore(t)
+
+	selected, _ := selectProfiles(st, listOptions{activeOnly: true})
+	if len(selected) == 0 {
+		t.Errorf("TestSelectActiveProfiles: no active Profile selected")
+		t.Fail()
+	}
+	for _, profile := range selected {
+		if !profile.IsActive {
+			t.Errorf("TestSelectActiveProfiles: Profile '%s' is not active", profile.Name)
+			t.Fail()
+		}
+	}
+} //TestSelectActiveProfiles
+
+func TestSortByLastUsed(t *testing.T) {
+
+	lastUsedFile := filepath.Join(t.TempDir(), "awsenv_last_used.json")
+	originalGetLastUsedFilePath := getLastUsedFilePath
+	getLastUsedFilePath = func() string { return lastUsedFile }
+	t.Cleanup(func() { getLastUsedFilePath = originalGetLastUsedFilePath })
+
+	content := `{"vault": "` + time.Now().Add(-time.Hour).UTC().Format(time.RFC3339) + `"}`
+	if err := ioutil.WriteFile(lastUsedFile, []byte(content), 0600); err != nil {
+		t.Fatalf("TestSortByLastUsed: %v", err)
+	}
+	markLastUsed("admin")
+
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/config_only_profiles_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/config_only_profiles_config")
+	st := loadTestStore(t)
+
+	selected, _ := selectProfiles(st, listOptions{sortBy: sortByLastUsed})
+	expected := []string{"admin", "vault", "prod", "sso-dev"}
+	if names := profileNames(selected); !reflect.DeepEqual(names, expected) {
+		t.Errorf("TestSortByLastUsed: order is not %v: %v", expected, names)
+		t.Fail()
+	}
+} //TestSortByLastUsed
+
+func TestFindProfilesByAccount(t *testing.T) {
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/account_keys_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/only_default_config")
+	st := loadTestStore(t)
+
+	expected := map[string][]string{
+		"581039954779": {"example"},
+		"609629065308": {"session"},
+		"123456789012": {},
+	}
+	for account, names := range expected {
+		if found := profileNames(findProfilesByAccount(st, account)); !reflect.DeepEqual(found, names) {
+			t.Errorf("TestFindProfilesByAccount: profiles of account %s are not %v: %v", account, names, found)
+			t.Fail()
+		}
+	}
+
+	for _, account := range []string{"581039954779", "012345678901"} {
+		if !accountIdPattern.MatchString(account) {
+			t.Errorf("TestFindProfilesByAccount: %s is a valid account id", account)
+			t.Fail()
+		}
+	}
+	for _, account := range []string{"", "58103995477", "5810399547790", "58103995477x"} {
+		if accountIdPattern.MatchString(account) {
+			t.Errorf("TestFindProfilesByAccount: '%s' is not a valid account id", account)
+			t.Fail()
+		}
+	}
+} //TestFindProfilesByAccount
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"github.com/BernhardLenz/awsenv/store"
+	"net/url"
+	"os"
+)
+
+const iamApiVersion = "2010-05-08"
+
+//AWSENV_IAM_ENDPOINT overrides the IAM endpoint, e.g. to run against a local stub server
+const iamEndpointEnv = "AWSENV_IAM_ENDPOINT"
+
+//status values of an access key
+const (
+	accessKeyActive   = "Active"
+	accessKeyInactive = "Inactive"
+)
+
+type createAccessKeyResponse struct {
+	AccessKeyId     string `xml:"CreateAccessKeyResult>AccessKey>AccessKeyId"`
+	SecretAccessKey string `xml:"CreateAccessKeyResult>AccessKey>SecretAccessKey"`
+	UserName        string `xml:"CreateAccessKeyResult>AccessKey>UserName"`
+}
+
+//iamEndpoint returns the global IAM endpoint which is always signed for us-east-1
+func iamEndpoint() string {
+	if endpoint := os.Getenv(iamEndpointEnv); endpoint != "" {
+		return endpoint
+	}
+	return "https://iam.amazonaws.com"
+} //iamEndpoint
+
+//callIam sends a signed IAM query api request. Without a UserName parameter IAM applies the action
+//to the user the credentials belong to.
+func callIam(creds store.Credentials, params url.Values, result interface{}) error {
+	params.Set("Version", iamApiVersion)
+	return callQueryApi("IAM", iamEndpoint(), "us-east-1", creds, params, result)
+} //callIam
+
+//createAccessKey creates a new access key for the user of the credentials
+func createAccessKey(creds store.Credentials) (store.Credentials, error) {
+	params := url.Values{}
+	params.Set("Action", "CreateAccessKey")
+
+	var response createAccessKeyResponse
+	if err := callIam(creds, params, &response); err != nil {
+		return store.Credentials{}, err
+	}
+	return store.Credentials{AccessKeyId: response.AccessKeyId, SecretAccessKey: response.SecretAccessKey}, nil
+} //createAccessKey
+
+//updateAccessKey sets the status of an access key of the user of the credentials to Active or Inactive
+func updateAccessKey(creds store.Credentials, accessKeyId string, status string) error {
+	params := url.Values{}
+	params.Set("Action", "UpdateAccessKey")
+	params.Set("AccessKeyId", accessKeyId)
+	params.Set("Status", status)
+	return callIam(creds, params, nil)
+} //updateAccessKey
+
+//deleteAccessKey deletes an access key of the user of the credentials
+func deleteAccessKey(creds store.Credentials, accessKeyId string) error {
+	params := url.Values{}
+	params.Set("Action", "DeleteAccessKey")
+	params.Set("AccessKeyId", accessKeyId)
+	return callIam(creds, params, nil)
+} //deleteAccessKey
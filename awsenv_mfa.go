@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"strconv"
+)
+
+//suffix of the Profile holding the temporary credentials created by the mfa command
+const mfaProfileSuffix = "-mfa"
+
+//createMfaSession calls STS GetSessionToken with the static keys and mfa_serial of a Profile and stores
+//the temporary credentials in the section <Profile>-mfa of the credentials file, which can then be activated.
+//The credentials file is not saved.
+func createMfaSession(st *store.Store, profileName string, tokenCode string, durationSeconds int) (string, error) {
+	if _, ok := st.Profile(profileName); !ok {
+		return "", &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: profileName}
+	}
+	if !st.HasCredentialsSection(profileName) {
+		return "", fmt.Errorf("Profile '%s' does not exist in the credentials file", profileName)
+	}
+	creds, err := st.StaticCredentials(profileName)
+	if err != nil {
+		return "", err
+	}
+	if creds.SessionToken != "" {
+		return "", fmt.Errorf("Profile '%s' already has temporary credentials", profileName)
+	}
+
+	serialNumber := mfaSerial(st, profileName)
+	if serialNumber == "" {
+		return "", fmt.Errorf("Profile '%s' has no mfa_serial", profileName)
+	}
+
+	config, _ := st.Config(profileName)
+	if durationSeconds == 0 && config.DurationSeconds != "" {
+		durationSeconds, err = strconv.Atoi(config.DurationSeconds)
+		if err != nil {
+			return "", fmt.Errorf("invalid duration_seconds '%s' in Profile '%s'", config.DurationSeconds, profileName)
+		}
+	}
+
+	region := config.Region
+	if region == "" {
+		region = st.DefaultConfig().Region
+	}
+
+	session, err := getSessionToken(creds, region, serialNumber, tokenCode, durationSeconds)
+	if err != nil {
+		return "", err
+	}
+
+	sessionProfileName := profileName + mfaProfileSuffix
+	if err := st.SetSessionProfile(sessionProfileName, session); err != nil {
+		return "", err
+	}
+	return sessionProfileName, nil
+} //createMfaSession
+
+//mfaSerial returns the mfa_serial of a Profile from the config file or, as some tools put it there,
+//from the credentials file
+func mfaSerial(st *store.Store, profileName string) string {
+	if config, _ := st.Config(profileName); config.MfaSerial != "" {
+		return config.MfaSerial
+	}
+	return st.CredentialsValue(profileName, "mfa_serial")
+} //mfaSerial
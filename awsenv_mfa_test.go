@@ -0,0 +1,130 @@
+package main
+
+import (
+	"github.com/BernhardLenz/awsenv/store"
+	"os"
+	"strings"
+	"testing"
+)
+
+func setupMfaTest(t *testing.T) (*store.Store, *[]stsStubRequest) {
+	server, requests := newStsStub(t)
+	t.Cleanup(func() { os.Unsetenv(stsEndpointEnv) })
+
+	os.Setenv(stsEndpointEnv, server.URL)
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/mfa_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/mfa_config")
+	return loadTestStore(t), requests
+} //setupMfaTest
+
+func TestCreateMfaSession(t *testing.T) {
+	st, requests := setupMfaTest(t)
+
+	sessionProfileName, err := createMfaSession(st, "prod", "123456", 0)
+	if err != nil {
+		t.Fatalf("TestCreateMfaSession: createMfaSession failed: %v", err)
+	}
+
+	if sessionProfileName != "prod-mfa" {
+		t.Errorf("TestCreateMfaSession: session Profile is not 'prod-mfa': %s ", sessionProfileName)
+		t.Fail()
+	}
+
+	if len(*requests) != 1 {
+		t.Fatalf("TestCreateMfaSession: STS has not been called once: %v", *requests)
+	}
+
+	request := (*requests)[0]
+	if request.signedBy != "12345678901234567891" {
+		t.Errorf("TestCreateMfaSession: request is not signed by the key of prod: %s ", request.signedBy)
+		t.Fail()
+	}
+
+	if request.form.Get("Action") != "GetSessionToken" ||
+		request.form.Get("SerialNumber") != "arn:aws:iam::123456789012:mfa/prod" ||
+		request.form.Get("TokenCode") != "123456" ||
+		request.form.Get("DurationSeconds") != "3600" {
+		t.Errorf("TestCreateMfaSession: unexpected GetSessionToken parameters: %v", request.form)
+		t.Fail()
+	}
+
+	profile := testProfile(t, st, "prod-mfa")
+	if profile.AwsAccessKeyId != "ASIATEMPORARY0001" ||
+		profile.AwsSecretAccessKey != "secret1" ||
+		profile.AwsSessionToken != "token1" ||
+		st.CredentialsValue("prod-mfa", "expiration") == "" {
+		t.Errorf("TestCreateMfaSession: prod-mfa section doesn't contain the temporary credentials: %v", profile)
+		t.Fail()
+	}
+} //TestCreateMfaSession
+
+func TestCreateMfaSessionBackup(t *testing.T) {
+	st, requests := setupMfaTest(t)
+
+	//mfa_serial in the credentials file, --duration overrides the default
+	_, err := createMfaSession(st, "dev", "123456", 900)
+	if err != nil {
+		t.Fatalf("TestCreateMfaSessionBackup: createMfaSession failed: %v", err)
+	}
+
+	request := (*requests)[0]
+	if request.form.Get("SerialNumber") != "arn:aws:iam::123456789012:mfa/dev" || request.form.Get("DurationSeconds") != "900" {
+		t.Errorf("TestCreateMfaSessionBackup: unexpected GetSessionToken parameters: %v", request.form)
+		t.Fail()
+	}
+
+	//the long-term keys which have been in dev-mfa are kept in a backup section which is not a Profile
+	backups := st.Backups()
+	if len(backups) != 1 || backups[0].Section != "dev-mfa" || backups[0].AwsAccessKeyId != "12345678901234567893" {
+		t.Errorf("TestCreateMfaSessionBackup: no backup of the dev-mfa section found: %v", backups)
+		t.Fail()
+	}
+	for _, profile := range st.Profiles() {
+		if strings.HasPrefix(profile.Name, "dev-mfa-") {
+			t.Errorf("TestCreateMfaSessionBackup: backup is listed as Profile: %s", profile.Name)
+			t.Fail()
+		}
+	}
+
+	if err := st.RestoreBackup(backups[0]); err != nil {
+		t.Fatalf("TestCreateMfaSessionBackup: RestoreBackup failed: %v", err)
+	}
+	if profile := testProfile(t, st, "dev-mfa"); profile.AwsAccessKeyId != "12345678901234567893" || profile.AwsSessionToken != "" {
+		t.Errorf("TestCreateMfaSessionBackup: backup wasn't restored into dev-mfa: %v", profile)
+		t.Fail()
+	}
+	if key := st.CredentialsValue(store.DefaultSection, "aws_access_key_id"); key != "12345678901234567890" {
+		t.Errorf("TestCreateMfaSessionBackup: backup of dev-mfa was restored into the default section: %s", key)
+		t.Fail()
+	}
+} //TestCreateMfaSessionBackup
+
+func TestCreateMfaSessionErrors(t *testing.T) {
+	st, requests := setupMfaTest(t)
+
+	if _, err := createMfaSession(st, "nomfa", "123456", 0); err == nil || !strings.Contains(err.Error(), "mfa_serial") {
+		t.Errorf("TestCreateMfaSessionErrors: missing mfa_serial not reported: %v", err)
+		t.Fail()
+	}
+
+	if _, err := createMfaSession(st, "missing", "123456", 0); err == nil {
+		t.Errorf("TestCreateMfaSessionErrors: missing Profile not reported")
+		t.Fail()
+	}
+
+	_, err := createMfaSession(st, "prod", "000000", 0)
+	if stsErr, ok := err.(*ApiError); !ok || stsErr.Code != "AccessDenied" {
+		t.Errorf("TestCreateMfaSessionErrors: wrong token code did not return AccessDenied: %v", err)
+		t.Fail()
+	}
+
+	if st.HasCredentialsSection("prod-mfa") {
+		t.Errorf("TestCreateMfaSessionErrors: prod-mfa section created although STS failed")
+		t.Fail()
+	}
+
+	if len(*requests) != 1 {
+		t.Errorf("TestCreateMfaSessionErrors: STS has not been called exactly once: %v", *requests)
+		t.Fail()
+	}
+} //TestCreateMfaSessionErrors
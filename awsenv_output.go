@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io"
+	"time"
+)
+
+//supported values for the --output flag of the list command
+const (
+	outputTable = "table"
+	outputJson  = "json"
+	outputYaml  = "yaml"
+	outputCsv   = "csv"
+)
+
+//profileRecord is the schema of a Profile in the json, yaml and csv output of the list command.
+//Secrets are never included and the access key id is masked like in the table output.
+//region and output contain the value of the default config if the Profile doesn't set them,
+//which is indicated by region_inherited and output_inherited.
+type profileRecord struct {
+	Name            string `json:"name"`
+	Kind            string `json:"kind"`
+	AwsAccessKeyId  string `json:"aws_access_key_id"`
+	Region          string `json:"region"`
+	RegionInherited bool   `json:"region_inherited"`
+	Output          string `json:"output"`
+	OutputInherited bool   `json:"output_inherited"`
+	IsActive        bool   `json:"is_active"`
+	IsShellActive   bool   `json:"is_shell_active"`
+	Expiration      string `json:"expiration"`
+}
+
+//profileRecordFields are the names of the fields in the order they are written
+var profileRecordFields = []string{
+	"name", "kind", "aws_access_key_id", "region", "region_inherited",
+	"output", "output_inherited", "is_active", "is_shell_active", "expiration",
+}
+
+func isSupportedOutput(output string) bool {
+	switch output {
+	case outputTable, outputJson, outputYaml, outputCsv:
+		return true
+	}
+	return false
+} //isSupportedOutput
+
+//profileRecords converts the profiles into records keeping their order
+func profileRecords(st *store.Store, profiles []store.Profile) []profileRecord {
+	defaultConfig := st.DefaultConfig()
+	records := make([]profileRecord, 0, len(profiles))
+	for _, profile := range profiles {
+		record := profileRecord{
+			Name:           profile.Name,
+			Kind:           profile.Kind,
+			AwsAccessKeyId: maskAccessKey(profile.AwsAccessKeyId, 20),
+			Region:         profile.Region,
+			Output:         profile.Output,
+			IsActive:       profile.IsActive,
+			IsShellActive:  profile.IsShellActive,
+		}
+		if record.Region == "" && defaultConfig.Region != "" {
+			record.Region = defaultConfig.Region
+			record.RegionInherited = true
+		}
+		if record.Output == "" && defaultConfig.Output != "" {
+			record.Output = defaultConfig.Output
+			record.OutputInherited = true
+		}
+		if !profile.Expiration.IsZero() {
+			record.Expiration = profile.Expiration.UTC().Format(time.RFC3339)
+		}
+		records = append(records, record)
+	}
+	return records
+} //profileRecords
+
+//values returns the fields of the record in the order of profileRecordFields, keeping the bool fields bool
+func (r profileRecord) values() []interface{} {
+	return []interface{}{
+		r.Name, r.Kind, r.AwsAccessKeyId, r.Region, r.RegionInherited,
+		r.Output, r.OutputInherited, r.IsActive, r.IsShellActive, r.Expiration,
+	}
+} //values
+
+func writeProfiles(w io.Writer, output string, records []profileRecord) error {
+	switch output {
+	case outputJson:
+		return writeProfilesJson(w, records)
+	case outputYaml:
+		return writeProfilesYaml(w, records)
+	case outputCsv:
+		return writeProfilesCsv(w, records)
+	}
+	return fmt.Errorf("unsupported output '%s'", output)
+} //writeProfiles
+
+func writeProfilesJson(w io.Writer, records []profileRecord) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+} //writeProfilesJson
+
+//writeProfilesYaml writes a yaml sequence. All strings are double quoted which makes them valid yaml
+//regardless of their content as yaml is a superset of json, e.g. a Profile named true stays a string.
+func writeProfilesYaml(w io.Writer, records []profileRecord) error {
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+	for _, record := range records {
+		values := record.values()
+		for i, field := range profileRecordFields {
+			prefix := "  "
+			if i == 0 {
+				prefix = "- "
+			}
+			value, _ := json.Marshal(values[i])
+			if _, err := fmt.Fprintf(w, "%s%s: %s\n", prefix, field, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+} //writeProfilesYaml
+
+func writeProfilesCsv(w io.Writer, records []profileRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(profileRecordFields); err != nil {
+		return err
+	}
+	for _, record := range records {
+		row := make([]string, 0, len(profileRecordFields))
+		for _, value := range record.values() {
+			row = append(row, fmt.Sprint(value))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+} //writeProfilesCsv
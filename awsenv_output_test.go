@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"github.com/BernhardLenz/awsenv/store"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//go test -run TestListOutput -update regenerates the golden files
+var update = flag.Bool("update", false, "update the golden files in testdata/golden")
+
+func TestListOutput(t *testing.T) {
+
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/profile_sections_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/profile_sections_config")
+	st := loadTestStore(t)
+
+	for _, output := range []string{outputJson, outputYaml, outputCsv} {
+		var buf bytes.Buffer
+		if err := writeProfiles(&buf, output, profileRecords(st, st.Profiles())); err != nil {
+			t.Fatalf("TestListOutput: writeProfiles %s failed: %v", output, err)
+		}
+
+		goldenFileName := filepath.Join("testdata", "golden", "list."+output)
+		if *update {
+			_ = os.MkdirAll(filepath.Dir(goldenFileName), 0755)
+			_ = ioutil.WriteFile(goldenFileName, buf.Bytes(), 0644)
+		}
+
+		expected, err := ioutil.ReadFile(goldenFileName)
+		if err != nil {
+			t.Fatalf("TestListOutput: %v", err)
+		}
+
+		if !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("TestListOutput: %s output doesn't match %s:\n%s", output, goldenFileName, buf.String())
+			t.Fail()
+		}
+	}
+} //TestListOutput
+
+func TestListOutputEmpty(t *testing.T) {
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/only_default_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/only_default_config")
+	st := loadTestStore(t)
+
+	expected := map[string]string{
+		outputJson: "[]\n",
+		outputYaml: "[]\n",
+		outputCsv:  "name,kind,aws_access_key_id,region,region_inherited,output,output_inherited,is_active,is_shell_active,expiration\n",
+	}
+	for output, want := range expected {
+		var buf bytes.Buffer
+		_ = writeProfiles(&buf, output, profileRecords(st, []store.Profile{}))
+		if buf.String() != want {
+			t.Errorf("TestListOutputEmpty: %s output is not %q: %q", output, want, buf.String())
+			t.Fail()
+		}
+	}
+} //TestListOutputEmpty
+
+func TestListOutputYamlBoolNames(t *testing.T) {
+	records := []profileRecord{{Name: "true", Kind: store.KindStatic, Region: "false", Output: "true", IsActive: true}}
+
+	var buf bytes.Buffer
+	if err := writeProfiles(&buf, outputYaml, records); err != nil {
+		t.Fatalf("TestListOutputYamlBoolNames: %v", err)
+	}
+	expected := "- name: \"true\"\n" +
+		"  kind: \"static\"\n" +
+		"  aws_access_key_id: \"\"\n" +
+		"  region: \"false\"\n" +
+		"  region_inherited: false\n" +
+		"  output: \"true\"\n" +
+		"  output_inherited: false\n" +
+		"  is_active: true\n" +
+		"  is_shell_active: false\n" +
+		"  expiration: \"\"\n"
+	if buf.String() != expected {
+		t.Errorf("TestListOutputYamlBoolNames: yaml is not\n%s\nactual:\n%s", expected, buf.String())
+		t.Fail()
+	}
+} //TestListOutputYamlBoolNames
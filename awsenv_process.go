@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io"
+	"time"
+)
+
+//credentialProcessOutput is the Version 1 format the aws cli and sdks expect from a credential_process.
+//Expiration is omitted for long-term keys so that they are not refreshed.
+type credentialProcessOutput struct {
+	Version         int    `json:"Version"`
+	AccessKeyId     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken,omitempty"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+//credentialProcessCommand returns the credential_process which provides the credentials of a Profile via awsenv
+func credentialProcessCommand(profileName string) string {
+	return "awsenv credential-process " + profileName
+} //credentialProcessCommand
+
+//credentialProcessCredentials resolves the credentials of a static or role Profile like the other commands.
+//Profiles which get their credentials from sso, credential_process or a web identity token are not supported.
+func credentialProcessCredentials(st *store.Store, profileName string) (store.Credentials, error) {
+	profile, ok := st.Profile(profileName)
+	if !ok {
+		return store.Credentials{}, &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: profileName}
+	}
+	if profile.Kind != store.KindStatic && profile.Kind != store.KindRole {
+		return store.Credentials{}, fmt.Errorf("Profile '%s' of kind %s can't be provided as credential_process", profileName, profile.Kind)
+	}
+
+	creds, err := resolveCredentials(st, profileName)
+	if err != nil {
+		return store.Credentials{}, err
+	}
+	if !creds.Expiration.IsZero() && creds.Expiration.Before(time.Now()) {
+		return store.Credentials{}, fmt.Errorf("the credentials of Profile '%s' expired at %s", profileName, formatExpiration(creds.Expiration))
+	}
+	return creds, nil
+} //credentialProcessCredentials
+
+//writeCredentialProcess writes the credentials in the format of credential_process
+func writeCredentialProcess(w io.Writer, creds store.Credentials) error {
+	output := credentialProcessOutput{
+		Version:         1,
+		AccessKeyId:     creds.AccessKeyId,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+	}
+	if !creds.Expiration.IsZero() {
+		output.Expiration = creds.Expiration.UTC().Format(time.RFC3339)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+} //writeCredentialProcess
+
+//wireProfile sets the credential_process of the target Profile, usually default, to awsenv credential-process
+//so the aws cli gets the credentials of the Profile from awsenv. The files are not saved.
+func wireProfile(st *store.Store, profileName string, targetName string) error {
+	profile, ok := st.Profile(profileName)
+	if !ok {
+		return &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: profileName}
+	}
+	if profile.Kind != store.KindStatic && profile.Kind != store.KindRole {
+		return fmt.Errorf("Profile '%s' of kind %s can't be provided as credential_process", profileName, profile.Kind)
+	}
+	if targetName == profileName {
+		return fmt.Errorf("Profile '%s' can't get its credentials from itself", profileName)
+	}
+	return st.SetCredentialProcess(targetName, credentialProcessCommand(profileName))
+} //wireProfile
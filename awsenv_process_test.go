@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCredentialProcessStatic(t *testing.T) {
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/mfa_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/mfa_config")
+	st := loadTestStore(t)
+
+	creds, err := credentialProcessCredentials(st, "prod")
+	if err != nil {
+		t.Fatalf("TestCredentialProcessStatic: credentialProcessCredentials failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeCredentialProcess(&buf, creds); err != nil {
+		t.Fatalf("TestCredentialProcessStatic: writeCredentialProcess failed: %v", err)
+	}
+
+	//long-term keys have no SessionToken and Expiration
+	expected := `{
+  "Version": 1,
+  "AccessKeyId": "12345678901234567891",
+  "SecretAccessKey": "1234567890123456789012345678901234567891"
+}
+`
+	if buf.String() != expected {
+		t.Errorf("TestCredentialProcessStatic: unexpected output:\n%s", buf.String())
+		t.Fail()
+	}
+
+	if _, err := credentialProcessCredentials(st, "missing"); exitCode(err) != exitProfileNotFound {
+		t.Errorf("TestCredentialProcessStatic: Profile not found not returned for a missing Profile: %v", err)
+		t.Fail()
+	}
+} //TestCredentialProcessStatic
+
+func TestCredentialProcessRole(t *testing.T) {
+	st, _ := setupRoleTest(t)
+
+	creds, err := credentialProcessCredentials(st, "admin")
+	if err != nil {
+		t.Fatalf("TestCredentialProcessRole: credentialProcessCredentials failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_ = writeCredentialProcess(&buf, creds)
+	var output credentialProcessOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("TestCredentialProcessRole: invalid json: %v\n%s", err, buf.String())
+	}
+	expiration, err := time.Parse(time.RFC3339, output.Expiration)
+	if output.Version != 1 || output.AccessKeyId != "ASIATEMPORARY0001" || output.SessionToken != "token1" || err != nil || expiration.Before(time.Now()) {
+		t.Errorf("TestCredentialProcessRole: unexpected output:\n%s", buf.String())
+		t.Fail()
+	}
+} //TestCredentialProcessRole
+
+func TestWireProfile(t *testing.T) {
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/config_only_profiles_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/config_only_profiles_config")
+	st := loadTestStore(t)
+
+	if err := wireProfile(st, "sso-dev", "default"); err == nil {
+		t.Errorf("TestWireProfile: sso Profile has been wired")
+		t.Fail()
+	}
+	if err := wireProfile(st, "prod", "prod"); err == nil {
+		t.Errorf("TestWireProfile: Profile has been wired to itself")
+		t.Fail()
+	}
+
+	if err := wireProfile(st, "prod", "default"); err != nil {
+		t.Fatalf("TestWireProfile: wireProfile failed: %v", err)
+	}
+	if process := st.DefaultConfig().CredentialProcess; process != "awsenv credential-process prod" {
+		t.Errorf("TestWireProfile: unexpected credential_process of the default config: %s", process)
+		t.Fail()
+	}
+} //TestWireProfile
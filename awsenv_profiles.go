@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io"
+	"os"
+	"strings"
+)
+
+//addOptions are the flags of the add command, missing values are read from stdin
+type addOptions struct {
+	accessKeyId     string
+	secretAccessKey string
+	region          string
+	output          string
+	roleArn         string
+	sourceProfile   string
+	mfaSerial       string
+}
+
+//readAddOptions asks for the values which are not given as flags like 'aws configure' does. Without
+//--access-key-id and --role-arn the access key, secret, region and output are read line by line, so they
+//can also be piped in. With --access-key-id only a missing secret is read. Prompts are written to stderr
+//if the input is a terminal.
+func readAddOptions(in io.Reader, options *addOptions) error {
+	type prompt struct {
+		text  string
+		value *string
+	}
+	var prompts []prompt
+	if options.accessKeyId == "" && options.roleArn == "" {
+		prompts = []prompt{
+			{"AWS Access Key ID", &options.accessKeyId},
+			{"AWS Secret Access Key", &options.secretAccessKey},
+			{"Default region name", &options.region},
+			{"Default output format", &options.output},
+		}
+	} else if options.accessKeyId != "" {
+		prompts = []prompt{{"AWS Secret Access Key", &options.secretAccessKey}}
+	}
+
+	file, ok := in.(*os.File)
+	interactive := ok && isTerminal(file)
+
+	reader := bufio.NewReader(in)
+	for _, p := range prompts {
+		if *p.value != "" {
+			continue
+		}
+		if interactive {
+			fmt.Fprintf(os.Stderr, "%s: ", p.text)
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read %s: %v", p.text, err)
+		}
+		*p.value = strings.TrimSpace(line)
+	}
+
+	if options.accessKeyId == "" && options.roleArn == "" {
+		return &usageError{"add requires an access key or --role-arn!"}
+	}
+	if options.accessKeyId != "" && options.secretAccessKey == "" {
+		return &usageError{"add requires the secret access key of the access key!"}
+	}
+	return nil
+} //readAddOptions
+
+//addProfile creates a Profile from the options. The files are not saved.
+func addProfile(st *store.Store, profileName string, options addOptions) error {
+	creds := store.Credentials{AccessKeyId: options.accessKeyId, SecretAccessKey: options.secretAccessKey}
+
+	settings := make([]store.Setting, 0)
+	for _, setting := range []store.Setting{
+		{Name: "region", Value: options.region},
+		{Name: "output", Value: options.output},
+		{Name: "role_arn", Value: options.roleArn},
+		{Name: "source_profile", Value: options.sourceProfile},
+		{Name: "mfa_serial", Value: options.mfaSerial},
+	} {
+		if setting.Value != "" {
+			settings = append(settings, setting)
+		}
+	}
+
+	if options.sourceProfile != "" {
+		if _, ok := st.Profile(options.sourceProfile); !ok {
+			return &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: options.sourceProfile}
+		}
+	}
+	return st.AddProfile(profileName, creds, settings)
+} //addProfile
+
+//removeProfile deletes a Profile and warns about the profiles which still reference it. The files are not saved.
+func removeProfile(st *store.Store, profileName string, force bool) error {
+	references := st.ProfileReferences(profileName)
+	if err := st.RemoveProfile(profileName, force); err != nil {
+		if errors.Is(err, store.ErrProfileActive) {
+			return fmt.Errorf("%w! Activate another Profile first or use --force to remove it anyway.", err)
+		}
+		return err
+	}
+	if len(references) > 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: source_profile of %s still references the removed Profile '%s'.\n",
+			strings.Join(references, ", "), profileName)
+	}
+	return nil
+} //removeProfile
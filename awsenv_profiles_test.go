@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReadAddOptions(t *testing.T) {
+	var options addOptions
+	input := "AKIAADDED00000000001\nsecret\neu-west-1\n\n"
+	if err := readAddOptions(strings.NewReader(input), &options); err != nil {
+		t.Fatalf("TestReadAddOptions: readAddOptions failed: %v", err)
+	}
+	expected := addOptions{accessKeyId: "AKIAADDED00000000001", secretAccessKey: "secret", region: "eu-west-1"}
+	if options != expected {
+		t.Errorf("TestReadAddOptions: unexpected options read from stdin: %v", options)
+		t.Fail()
+	}
+
+	//only the missing secret is read if the access key is given as flag
+	options = addOptions{accessKeyId: "AKIAADDED00000000001", region: "us-east-1"}
+	if err := readAddOptions(strings.NewReader("secret\n"), &options); err != nil || options.secretAccessKey != "secret" || options.region != "us-east-1" {
+		t.Errorf("TestReadAddOptions: unexpected options %v or error %v", options, err)
+		t.Fail()
+	}
+
+	//roles don't need any credentials
+	options = addOptions{roleArn: "arn:aws:iam::123456789012:role/Admin", sourceProfile: "prod"}
+	if err := readAddOptions(strings.NewReader(""), &options); err != nil {
+		t.Errorf("TestReadAddOptions: readAddOptions failed for a role: %v", err)
+		t.Fail()
+	}
+
+	options = addOptions{}
+	var usage *usageError
+	if err := readAddOptions(strings.NewReader(""), &options); !errors.As(err, &usage) {
+		t.Errorf("TestReadAddOptions: usageError not returned without an access key: %v", err)
+		t.Fail()
+	}
+} //TestReadAddOptions
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//method pointer which can be changed during test case execution
+var readTokenCode = promptTokenCode
+
+//resolveCredentials returns the credentials of a Profile. For role profiles the source_profile chain
+//is resolved and the role is assumed unless valid credentials are found in the cache.
+func resolveCredentials(st *store.Store, profileName string) (store.Credentials, error) {
+	return resolveCredentialsChain(st, profileName, make(map[string]bool))
+} //resolveCredentials
+
+func resolveCredentialsChain(st *store.Store, profileName string, visited map[string]bool) (store.Credentials, error) {
+	if visited[profileName] {
+		return store.Credentials{}, fmt.Errorf("source_profile cycle detected at Profile '%s'", profileName)
+	}
+	visited[profileName] = true
+
+	config, _ := st.Config(profileName)
+	if config.RoleArn == "" {
+		return st.StaticCredentials(profileName)
+	}
+
+	if creds, ok := store.ReadCachedRoleCredentials(config); ok {
+		verbosef("Using cached credentials of role %s", config.RoleArn)
+		return creds, nil
+	}
+
+	var sourceCreds store.Credentials
+	var err error
+	switch {
+	case config.SourceProfile == profileName:
+		//a role Profile may use its own static keys as source
+		sourceCreds, err = st.StaticCredentials(profileName)
+	case config.SourceProfile != "":
+		sourceCreds, err = resolveCredentialsChain(st, config.SourceProfile, visited)
+	case config.CredentialSource == "Environment":
+		sourceCreds = store.Credentials{
+			AccessKeyId:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		if sourceCreds.AccessKeyId == "" {
+			err = fmt.Errorf("credential_source Environment of Profile '%s' requires AWS_ACCESS_KEY_ID to be set", profileName)
+		}
+	case config.CredentialSource != "":
+		err = fmt.Errorf("credential_source '%s' of Profile '%s' is not supported", config.CredentialSource, profileName)
+	default:
+		err = fmt.Errorf("Profile '%s' has a role_arn but neither source_profile nor credential_source", profileName)
+	}
+	if err != nil {
+		return store.Credentials{}, err
+	}
+
+	input := assumeRoleInput{
+		roleArn:         config.RoleArn,
+		roleSessionName: config.RoleSessionName,
+		externalId:      config.ExternalId,
+		serialNumber:    config.MfaSerial,
+	}
+	if input.roleSessionName == "" {
+		input.roleSessionName = "awsenv-session-" + strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	if config.DurationSeconds != "" {
+		input.durationSeconds, err = strconv.Atoi(config.DurationSeconds)
+		if err != nil {
+			return store.Credentials{}, fmt.Errorf("invalid duration_seconds '%s' in Profile '%s'", config.DurationSeconds, profileName)
+		}
+	}
+	if input.serialNumber != "" {
+		input.tokenCode, err = readTokenCode(input.serialNumber)
+		if err != nil {
+			return store.Credentials{}, err
+		}
+	}
+
+	region := config.Region
+	if region == "" {
+		region = st.DefaultConfig().Region
+	}
+	creds, assumedRoleUser, err := assumeRole(sourceCreds, region, input)
+	if err != nil {
+		return store.Credentials{}, err
+	}
+
+	//the credentials are still usable if they can't be cached
+	if err := store.WriteCachedRoleCredentials(config, store.RoleCacheFile{Credentials: creds, AssumedRoleUser: assumedRoleUser}); err != nil {
+		verbosef("Failed to cache the credentials of role %s: %v", config.RoleArn, err)
+	}
+
+	return creds, nil
+} //resolveCredentialsChain
+
+//promptTokenCode asks for the current code of the MFA device on stderr so that the prompt
+//doesn't end up in the output of activate --export
+func promptTokenCode(serialNumber string) (string, error) {
+	fmt.Fprintf(os.Stderr, "Enter MFA code for %s: ", serialNumber)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read MFA code: %v", err)
+	}
+	return strings.TrimSpace(line), nil
+} //promptTokenCode
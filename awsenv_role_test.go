@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stsStubRequest struct {
+	signedBy string
+	form     url.Values
+}
+
+//newStsStub starts a local STS stand-in which hands out a new key for every AssumeRole or GetSessionToken
+//call and records the requests together with the access key ids they have been signed with
+func newStsStub(t *testing.T) (*httptest.Server, *[]stsStubRequest) {
+	requests := []stsStubRequest{}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		authorization := r.Header.Get("Authorization")
+		credential := strings.SplitN(strings.TrimPrefix(authorization, "AWS4-HMAC-SHA256 Credential="), "/", 2)[0]
+		requests = append(requests, stsStubRequest{credential, r.Form})
+
+		action := r.Form.Get("Action")
+		if action != "AssumeRole" && action != "GetSessionToken" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>InvalidAction</Code><Message>unexpected action</Message></Error></ErrorResponse>`)
+			return
+		}
+		if r.Form.Get("SerialNumber") != "" && r.Form.Get("TokenCode") != "123456" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>AccessDenied</Code><Message>MultiFactorAuthentication failed</Message></Error></ErrorResponse>`)
+			return
+		}
+
+		calls++
+		expiration := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, `<%[1]sResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <%[1]sResult>
+    <Credentials>
+      <AccessKeyId>ASIATEMPORARY%04[2]d</AccessKeyId>
+      <SecretAccessKey>secret%[2]d</SecretAccessKey>
+      <SessionToken>token%[2]d</SessionToken>
+      <Expiration>%[3]s</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>%[4]s/%[5]s</Arn>
+      <AssumedRoleId>AROAEXAMPLE:%[5]s</AssumedRoleId>
+    </AssumedRoleUser>
+  </%[1]sResult>
+</%[1]sResponse>`, action, calls, expiration, r.Form.Get("RoleArn"), r.Form.Get("RoleSessionName"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+} //newStsStub
+
+func setupRoleTest(t *testing.T) (*store.Store, *[]stsStubRequest) {
+	server, requests := newStsStub(t)
+	cacheDir := t.TempDir()
+
+	origCliCacheDir := store.CliCacheDir
+	origReadTokenCode := readTokenCode
+	t.Cleanup(func() {
+		store.CliCacheDir = origCliCacheDir
+		readTokenCode = origReadTokenCode
+		os.Unsetenv(stsEndpointEnv)
+	})
+	store.CliCacheDir = func() string { return cacheDir }
+	readTokenCode = func(serialNumber string) (string, error) { return "123456", nil }
+
+	os.Setenv(stsEndpointEnv, server.URL)
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/config_only_profiles_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/role_chain_config")
+	return loadTestStore(t), requests
+} //setupRoleTest
+
+func TestResolveRoleChain(t *testing.T) {
+	st, requests := setupRoleTest(t)
+
+	creds, err := resolveCredentials(st, "chained")
+	if err != nil {
+		t.Fatalf("TestResolveRoleChain: resolveCredentials failed: %v", err)
+	}
+
+	//admin is assumed with the static keys of prod, chained with the temporary keys of admin
+	expectedSignedBy := []string{"12345678901234567890", "ASIATEMPORARY0001"}
+	if len(*requests) != 2 || (*requests)[0].signedBy != expectedSignedBy[0] || (*requests)[1].signedBy != expectedSignedBy[1] {
+		t.Errorf("TestResolveRoleChain: requests are not signed by %v: %v", expectedSignedBy, *requests)
+		t.Fail()
+	}
+
+	if form := (*requests)[1].form; form.Get("RoleSessionName") != "chained-session" || form.Get("ExternalId") != "ext-1234" {
+		t.Errorf("TestResolveRoleChain: role_session_name and external_id of chained have not been sent: %v", form)
+		t.Fail()
+	}
+
+	if creds.AccessKeyId != "ASIATEMPORARY0002" || creds.SessionToken != "token2" {
+		t.Errorf("TestResolveRoleChain: credentials are not the ones of the second AssumeRole call: %v", creds)
+		t.Fail()
+	}
+
+	config, _ := st.Config("chained")
+	if _, err := os.Stat(filepath.Join(store.CliCacheDir(), store.RoleCacheKey(config)+".json")); err != nil {
+		t.Errorf("TestResolveRoleChain: credentials of chained have not been cached: %v", err)
+		t.Fail()
+	}
+
+	//the second call is served from the cache
+	creds, err = resolveCredentials(st, "chained")
+	if err != nil || creds.AccessKeyId != "ASIATEMPORARY0002" || len(*requests) != 2 {
+		t.Errorf("TestResolveRoleChain: cached credentials have not been used: %v %v %v", creds, err, *requests)
+		t.Fail()
+	}
+
+	//cached role credentials are shown in the profile list
+	st = loadTestStore(t)
+	if profile := testProfile(t, st, "chained"); profile.Expiration.IsZero() || profile.AwsSessionToken != "token2" {
+		t.Errorf("TestResolveRoleChain: chained Profile doesn't have the cached credentials: %v", profile)
+		t.Fail()
+	}
+} //TestResolveRoleChain
+
+func TestResolveRoleWithMfa(t *testing.T) {
+	st, _ := setupRoleTest(t)
+
+	serialNumbers := []string{}
+	readTokenCode = func(serialNumber string) (string, error) {
+		serialNumbers = append(serialNumbers, serialNumber)
+		return "123456", nil
+	}
+
+	creds, err := resolveCredentials(st, "mfa")
+	if err != nil {
+		t.Fatalf("TestResolveRoleWithMfa: resolveCredentials failed: %v", err)
+	}
+
+	if len(serialNumbers) != 1 || serialNumbers[0] != "arn:aws:iam::123456789012:mfa/user" {
+		t.Errorf("TestResolveRoleWithMfa: token code has not been requested for the mfa_serial: %v", serialNumbers)
+		t.Fail()
+	}
+
+	if creds.AccessKeyId == "" {
+		t.Errorf("TestResolveRoleWithMfa: no credentials returned")
+		t.Fail()
+	}
+
+	readTokenCode = func(serialNumber string) (string, error) { return "000000", nil }
+	config, _ := st.Config("mfa")
+	_ = os.Remove(filepath.Join(store.CliCacheDir(), store.RoleCacheKey(config)+".json"))
+
+	_, err = resolveCredentials(st, "mfa")
+	if stsErr, ok := err.(*ApiError); !ok || stsErr.Code != "AccessDenied" {
+		t.Errorf("TestResolveRoleWithMfa: wrong token code did not return AccessDenied: %v", err)
+		t.Fail()
+	}
+} //TestResolveRoleWithMfa
+
+func TestResolveRoleCycle(t *testing.T) {
+	st, requests := setupRoleTest(t)
+
+	_, err := resolveCredentials(st, "cycle-a")
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("TestResolveRoleCycle: source_profile cycle not detected: %v", err)
+		t.Fail()
+	}
+
+	if len(*requests) != 0 {
+		t.Errorf("TestResolveRoleCycle: STS has been called: %v", *requests)
+		t.Fail()
+	}
+} //TestResolveRoleCycle
+
+func TestSignRequest(t *testing.T) {
+	//example request from the AWS Signature Version 4 documentation
+	req, _ := http.NewRequest("GET", "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	now, _ := time.Parse("20060102T150405Z", "20150830T123600Z")
+
+	creds := store.Credentials{AccessKeyId: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signRequest(req, []byte{}, creds, "us-east-1", "iam", now)
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if actual := req.Header.Get("Authorization"); actual != expected {
+		t.Errorf("TestSignRequest: Authorization header is not\n%s\nactual:\n%s", expected, actual)
+		t.Fail()
+	}
+} //TestSignRequest
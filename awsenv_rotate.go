@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"time"
+)
+
+//number of GetCallerIdentity calls before a new access key is given up, IAM needs a few seconds until it can be used
+const verifyAccessKeyAttempts = 5
+
+//method pointer which can be changed during test case execution
+var waitForAccessKey = func(attempt int) {
+	time.Sleep(time.Duration(attempt) * 3 * time.Second)
+}
+
+//rotateAccessKey replaces the access key of a static Profile: a new key is created with the old one, verified
+//with STS GetCallerIdentity and written to the Profile and to all other sections sharing the old key, e.g.
+//the default section if the Profile is active.
+//Only then the old key is deactivated and deleted with the new key. If any step fails the previous ones are
+//rolled back, so either the old or the new key is left in place. The credentials file is saved.
+//The new access key id is returned.
+func rotateAccessKey(st *store.Store, profileName string) (string, error) {
+	profile, ok := st.Profile(profileName)
+	if !ok {
+		return "", &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: profileName}
+	}
+	if !st.HasCredentialsSection(profileName) || profile.Kind != store.KindStatic {
+		return "", fmt.Errorf("Profile '%s' has no access key in the credentials file", profileName)
+	}
+	oldCreds, err := st.StaticCredentials(profileName)
+	if err != nil {
+		return "", err
+	}
+	if oldCreds.SessionToken != "" {
+		return "", fmt.Errorf("Profile '%s' has temporary credentials which can't be rotated", profileName)
+	}
+
+	newCreds, err := createAccessKey(oldCreds)
+	if err != nil {
+		return "", fmt.Errorf("failed to create a new access key: %w", err)
+	}
+	verbosef("Created access key %s", newCreds.AccessKeyId)
+
+	//steps undoing the rotation which are run in reverse order if a later step fails
+	rollback := []func() error{
+		func() error { return deleteAccessKey(oldCreds, newCreds.AccessKeyId) },
+	}
+	fail := func(err error) (string, error) {
+		for i := len(rollback) - 1; i >= 0; i-- {
+			if rollbackErr := rollback[i](); rollbackErr != nil {
+				return "", fmt.Errorf("%w. The rollback failed as well, check the access keys of the user: %v", err, rollbackErr)
+			}
+		}
+		return "", fmt.Errorf("%w. The rotation has been rolled back", err)
+	}
+
+	if err := verifyAccessKey(newCreds, effectiveRegion(st, profile)); err != nil {
+		return fail(fmt.Errorf("new access key %s can't be used: %w", newCreds.AccessKeyId, err))
+	}
+
+	err = st.ReplaceStaticCredentials(profileName, newCreds)
+	if err == nil {
+		err = st.Save()
+	}
+	if err != nil {
+		return fail(err)
+	}
+	rollback = append(rollback, func() error {
+		if err := st.ReplaceStaticCredentials(profileName, oldCreds); err != nil {
+			return err
+		}
+		return st.Save()
+	})
+
+	if err := updateAccessKey(newCreds, oldCreds.AccessKeyId, accessKeyInactive); err != nil {
+		return fail(fmt.Errorf("failed to deactivate the old access key: %w", err))
+	}
+	rollback = append(rollback, func() error {
+		return updateAccessKey(newCreds, oldCreds.AccessKeyId, accessKeyActive)
+	})
+
+	if err := deleteAccessKey(newCreds, oldCreds.AccessKeyId); err != nil {
+		return fail(fmt.Errorf("failed to delete the old access key: %w", err))
+	}
+	return newCreds.AccessKeyId, nil
+} //rotateAccessKey
+
+//verifyAccessKey calls STS GetCallerIdentity with a new access key until it succeeds
+func verifyAccessKey(creds store.Credentials, region string) error {
+	var err error
+	for attempt := 1; attempt <= verifyAccessKeyAttempts; attempt++ {
+		if _, err = getCallerIdentity(creds, region); err == nil {
+			return nil
+		}
+		verbosef("Access key %s is not usable yet: %v", creds.AccessKeyId, err)
+		if attempt < verifyAccessKeyAttempts {
+			waitForAccessKey(attempt)
+		}
+	}
+	return err
+} //verifyAccessKey
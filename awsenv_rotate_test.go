@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+//iamStub is a local IAM and STS stand-in which keeps the access keys of a single user.
+//Requests signed with unknown or inactive keys are rejected like by AWS.
+type iamStub struct {
+	keys       map[string]string
+	actions    []string
+	failAction string
+	failures   int
+	created    int
+}
+
+func newIamStub(t *testing.T, accessKeyIds ...string) *iamStub {
+	stub := &iamStub{keys: make(map[string]string)}
+	for _, accessKeyId := range accessKeyIds {
+		stub.keys[accessKeyId] = accessKeyActive
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		authorization := r.Header.Get("Authorization")
+		signedBy := strings.SplitN(strings.TrimPrefix(authorization, "AWS4-HMAC-SHA256 Credential="), "/", 2)[0]
+		action := r.Form.Get("Action")
+		stub.actions = append(stub.actions, action+" "+signedBy)
+
+		if stub.keys[signedBy] != accessKeyActive {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>InvalidClientTokenId</Code><Message>invalid key</Message></Error></ErrorResponse>`)
+			return
+		}
+		//the action fails the given number of times so that the rollback can succeed
+		if action == stub.failAction && stub.failures > 0 {
+			stub.failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>ServiceFailure</Code><Message>failure</Message></Error></ErrorResponse>`)
+			return
+		}
+
+		switch action {
+		case "CreateAccessKey":
+			stub.created++
+			accessKeyId := fmt.Sprintf("AKIANEWKEY%010d", stub.created)
+			stub.keys[accessKeyId] = accessKeyActive
+			fmt.Fprintf(w, `<CreateAccessKeyResponse><CreateAccessKeyResult><AccessKey><UserName>user</UserName>
+<AccessKeyId>%s</AccessKeyId><Status>Active</Status><SecretAccessKey>newsecret%d</SecretAccessKey>
+</AccessKey></CreateAccessKeyResult></CreateAccessKeyResponse>`, accessKeyId, stub.created)
+		case "UpdateAccessKey":
+			stub.keys[r.Form.Get("AccessKeyId")] = r.Form.Get("Status")
+			fmt.Fprint(w, `<UpdateAccessKeyResponse></UpdateAccessKeyResponse>`)
+		case "DeleteAccessKey":
+			delete(stub.keys, r.Form.Get("AccessKeyId"))
+			fmt.Fprint(w, `<DeleteAccessKeyResponse></DeleteAccessKeyResponse>`)
+		case "GetCallerIdentity":
+			fmt.Fprint(w, `<GetCallerIdentityResponse><GetCallerIdentityResult><Arn>arn:aws:iam::123456789012:user/user</Arn>
+<UserId>AIDAEXAMPLE</UserId><Account>123456789012</Account></GetCallerIdentityResult></GetCallerIdentityResponse>`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>InvalidAction</Code><Message>unexpected action</Message></Error></ErrorResponse>`)
+		}
+	}))
+
+	origWaitForAccessKey := waitForAccessKey
+	t.Cleanup(func() {
+		server.Close()
+		waitForAccessKey = origWaitForAccessKey
+		os.Unsetenv(iamEndpointEnv)
+		os.Unsetenv(stsEndpointEnv)
+	})
+	waitForAccessKey = func(attempt int) {}
+	os.Setenv(iamEndpointEnv, server.URL)
+	os.Setenv(stsEndpointEnv, server.URL)
+	return stub
+} //newIamStub
+
+//setupRotateTest copies a credentials file into a temporary directory as rotate saves it
+func setupRotateTest(t *testing.T, credentialsFileName string) string {
+	credentialsPath := copyTestdata(t, credentialsFileName, 0600)
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsPath)
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/only_default_config")
+	return credentialsPath
+} //setupRotateTest
+
+func TestRotateAccessKey(t *testing.T) {
+	stub := newIamStub(t, "12345678901234567890")
+	credentialsPath := setupRotateTest(t, "one_profile_matching_default_credentials")
+	st := loadTestStore(t)
+
+	newAccessKeyId, err := rotateAccessKey(st, "profile_matching_default_credentials")
+	if err != nil {
+		t.Fatalf("TestRotateAccessKey: rotateAccessKey failed: %v", err)
+	}
+
+	expectedActions := []string{
+		"CreateAccessKey 12345678901234567890",
+		"GetCallerIdentity AKIANEWKEY0000000001",
+		"UpdateAccessKey AKIANEWKEY0000000001",
+		"DeleteAccessKey AKIANEWKEY0000000001",
+	}
+	if strings.Join(stub.actions, "\n") != strings.Join(expectedActions, "\n") {
+		t.Errorf("TestRotateAccessKey: unexpected calls:\n%s", strings.Join(stub.actions, "\n"))
+		t.Fail()
+	}
+	if len(stub.keys) != 1 || stub.keys[newAccessKeyId] != accessKeyActive {
+		t.Errorf("TestRotateAccessKey: unexpected access keys of the user: %v", stub.keys)
+		t.Fail()
+	}
+
+	//the Profile is active, so the default section has been updated as well
+	st = loadTestStore(t)
+	profile := testProfile(t, st, "profile_matching_default_credentials")
+	if profile.AwsAccessKeyId != newAccessKeyId || profile.AwsSecretAccessKey != "newsecret1" || !profile.IsActive {
+		t.Errorf("TestRotateAccessKey: new access key not written to the active Profile: %v", profile)
+		t.Fail()
+	}
+	if content, _ := ioutil.ReadFile(credentialsPath); strings.Contains(string(content), "12345678901234567890") {
+		t.Errorf("TestRotateAccessKey: old access key still in the credentials file:\n%s", content)
+		t.Fail()
+	}
+} //TestRotateAccessKey
+
+func TestRotateSharedAccessKey(t *testing.T) {
+	newIamStub(t, "12345678901234567890")
+	setupRotateTest(t, "two_profiles_matching_default_credentials")
+	st := loadTestStore(t)
+
+	newAccessKeyId, err := rotateAccessKey(st, "profile1_matching_default_credentials")
+	if err != nil {
+		t.Fatalf("TestRotateSharedAccessKey: rotateAccessKey failed: %v", err)
+	}
+
+	//the old key has been deleted, so every section which used it needs the new one
+	st = loadTestStore(t)
+	for _, profileName := range []string{"profile1_matching_default_credentials", "profile2_matching_default_credentials", store.DefaultSection} {
+		if key := st.CredentialsValue(profileName, "aws_access_key_id"); key != newAccessKeyId {
+			t.Errorf("TestRotateSharedAccessKey: %s still has the old access key %s", profileName, key)
+			t.Fail()
+		}
+	}
+	if key := st.CredentialsValue("profile3_not_matching_default_credentials", "aws_access_key_id"); key != "1234567890123456789_" {
+		t.Errorf("TestRotateSharedAccessKey: the access key of another user has been replaced: %s", key)
+		t.Fail()
+	}
+} //TestRotateSharedAccessKey
+
+func TestRotateAccessKeyRollback(t *testing.T) {
+	failures := map[string]int{"GetCallerIdentity": verifyAccessKeyAttempts, "UpdateAccessKey": 1, "DeleteAccessKey": 1}
+	for failAction, count := range failures {
+		stub := newIamStub(t, "12345678901234567891")
+		stub.failAction = failAction
+		stub.failures = count
+		credentialsPath := setupRotateTest(t, "mfa_credentials")
+		original, _ := ioutil.ReadFile(credentialsPath)
+		st := loadTestStore(t)
+
+		if _, err := rotateAccessKey(st, "prod"); err == nil || !strings.Contains(err.Error(), "rolled back") {
+			t.Errorf("TestRotateAccessKeyRollback: no rollback reported if %s fails: %v", failAction, err)
+			t.Fail()
+		}
+
+		//only the old key is left and active
+		if len(stub.keys) != 1 || stub.keys["12345678901234567891"] != accessKeyActive {
+			t.Errorf("TestRotateAccessKeyRollback: unexpected access keys if %s fails: %v", failAction, stub.keys)
+			t.Fail()
+		}
+		st = loadTestStore(t)
+		if profile := testProfile(t, st, "prod"); profile.AwsAccessKeyId != "12345678901234567891" {
+			t.Errorf("TestRotateAccessKeyRollback: old access key not restored if %s fails: %s\n%s", failAction, profile.AwsAccessKeyId, original)
+			t.Fail()
+		}
+	}
+} //TestRotateAccessKeyRollback
+
+func TestRotateAccessKeyRetry(t *testing.T) {
+	//new access keys can't be used for a few seconds
+	stub := newIamStub(t, "12345678901234567891")
+	stub.failAction = "GetCallerIdentity"
+	stub.failures = verifyAccessKeyAttempts - 1
+	setupRotateTest(t, "mfa_credentials")
+	st := loadTestStore(t)
+
+	if _, err := rotateAccessKey(st, "prod"); err != nil {
+		t.Errorf("TestRotateAccessKeyRetry: rotateAccessKey failed: %v", err)
+		t.Fail()
+	}
+} //TestRotateAccessKeyRetry
+
+func TestRotateAccessKeyErrors(t *testing.T) {
+	newIamStub(t)
+	setupRotateTest(t, "mfa_credentials")
+	st := loadTestStore(t)
+
+	if _, err := rotateAccessKey(st, "missing"); exitCode(err) != exitProfileNotFound {
+		t.Errorf("TestRotateAccessKeyErrors: Profile not found not returned for a missing Profile: %v", err)
+		t.Fail()
+	}
+
+	//the stub doesn't know the key of prod
+	if _, err := rotateAccessKey(st, "prod"); err == nil {
+		t.Errorf("TestRotateAccessKeyErrors: no error returned for an invalid access key")
+		t.Fail()
+	}
+} //TestRotateAccessKeyErrors
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+//keys whose values are masked like the access key id
+var secretKeys = map[string]bool{
+	"aws_access_key_id":     true,
+	"aws_secret_access_key": true,
+	"aws_session_token":     true,
+	"aws_security_token":    true,
+}
+
+//keys of the default config which are used by profiles that don't set them
+var inheritedKeys = []string{"region", "output"}
+
+//environment variables which take precedence over keys of the profile, the first one set wins.
+//Empty variables are ignored unless allowEmpty is set, e.g. AWS_PAGER="" disables the pager.
+var settingEnvOverrides = []struct {
+	key        string
+	envs       []string
+	allowEmpty bool
+}{
+	{"aws_access_key_id", []string{"AWS_ACCESS_KEY_ID"}, false},
+	{"aws_secret_access_key", []string{"AWS_SECRET_ACCESS_KEY"}, false},
+	{"aws_session_token", []string{"AWS_SESSION_TOKEN"}, false},
+	{"region", []string{"AWS_REGION", "AWS_DEFAULT_REGION"}, false},
+	{"output", []string{"AWS_DEFAULT_OUTPUT"}, false},
+	{"ca_bundle", []string{"AWS_CA_BUNDLE"}, false},
+	{"endpoint_url", []string{"AWS_ENDPOINT_URL"}, false},
+	{"retry_mode", []string{"AWS_RETRY_MODE"}, false},
+	{"max_attempts", []string{"AWS_MAX_ATTEMPTS"}, false},
+	{"cli_pager", []string{"AWS_PAGER"}, true},
+	{"role_arn", []string{"AWS_ROLE_ARN"}, false},
+	{"role_session_name", []string{"AWS_ROLE_SESSION_NAME"}, false},
+	{"web_identity_token_file", []string{"AWS_WEB_IDENTITY_TOKEN_FILE"}, false},
+}
+
+//sources of an effective value besides the environment variables
+const (
+	sourceCredentials = "credentials"
+	sourceConfig      = "config"
+	sourceInherited   = "inherited"
+)
+
+//profileDetail is the schema of the show command output. Secrets are masked.
+type profileDetail struct {
+	Name          string             `json:"name"`
+	Kind          string             `json:"kind"`
+	IsActive      bool               `json:"is_active"`
+	IsShellActive bool               `json:"is_shell_active"`
+	Expiration    string             `json:"expiration"`
+	Settings      []settingDetail    `json:"settings"`
+	Effective     []effectiveSetting `json:"effective"`
+}
+
+//settingDetail is a key of the Profile with the file, section and line it has been read from.
+//Inherited is true for keys of the default config which the Profile doesn't set itself.
+type settingDetail struct {
+	Name        string          `json:"name"`
+	Value       string          `json:"value"`
+	File        string          `json:"file"`
+	Section     string          `json:"section"`
+	Line        int             `json:"line"`
+	Inherited   bool            `json:"inherited"`
+	SubSettings []settingDetail `json:"sub_settings,omitempty"`
+}
+
+//effectiveSetting is the value the aws cli uses. Source is credentials, config, inherited
+//or the name of the environment variable overriding the key.
+type effectiveSetting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+//lookupProfile returns a Profile by name. The default section is only listed as a Profile if it matches
+//no other Profile, but it can always be looked up.
+func lookupProfile(st *store.Store, profileName string) (store.Profile, bool) {
+	if profile, ok := st.Profile(profileName); ok {
+		return profile, true
+	}
+	if profileName == store.DefaultSection {
+		return st.DefaultProfile(), true
+	}
+	return store.Profile{}, false
+} //lookupProfile
+
+//maskSetting masks secrets via maskAccessKey
+func maskSetting(name string, value string) string {
+	if secretKeys[name] {
+		return maskAccessKey(value, 20)
+	}
+	return value
+} //maskSetting
+
+//newSettingDetail converts a Setting of the Profile, the section name depends on the file it is in
+func newSettingDetail(st *store.Store, profile store.Profile, setting store.Setting) settingDetail {
+	detail := settingDetail{
+		Name:    setting.Name,
+		Value:   maskSetting(setting.Name, setting.Value),
+		File:    st.CredentialsPath(),
+		Section: profile.Name,
+		Line:    setting.Line,
+	}
+	if setting.File == store.FileConfig {
+		config, _ := st.Config(profile.Name)
+		detail.File = st.ConfigPath()
+		detail.Section = config.SectionName
+	}
+	for _, subSetting := range setting.SubSettings {
+		detail.SubSettings = append(detail.SubSettings, newSettingDetail(st, profile, subSetting))
+	}
+	return detail
+} //newSettingDetail
+
+//profileDetails collects all keys of a Profile, the keys inherited from the default config
+//and the effective values after the overrides by environment variables
+func profileDetails(st *store.Store, profile store.Profile) profileDetail {
+	detail := profileDetail{
+		Name:          profile.Name,
+		Kind:          profile.Kind,
+		IsActive:      profile.IsActive,
+		IsShellActive: profile.IsShellActive,
+		Settings:      make([]settingDetail, 0),
+		Effective:     make([]effectiveSetting, 0),
+	}
+	if !profile.Expiration.IsZero() {
+		detail.Expiration = profile.Expiration.UTC().Format(time.RFC3339)
+	}
+
+	for _, setting := range profile.Settings {
+		detail.Settings = append(detail.Settings, newSettingDetail(st, profile, setting))
+	}
+
+	if profile.Name != store.DefaultSection {
+		defaultProfile := st.DefaultProfile()
+		for _, name := range inheritedKeys {
+			if _, ok := profile.Setting(name); ok {
+				continue
+			}
+			for _, setting := range defaultProfile.Settings {
+				if setting.Name == name && setting.File == store.FileConfig {
+					inherited := newSettingDetail(st, defaultProfile, setting)
+					inherited.Inherited = true
+					detail.Settings = append(detail.Settings, inherited)
+				}
+			}
+		}
+	}
+
+	effective := make(map[string]int)
+	for _, setting := range detail.Settings {
+		if len(setting.SubSettings) > 0 {
+			continue
+		}
+		source := sourceConfig
+		if setting.Inherited {
+			source = sourceInherited
+		} else if setting.File == st.CredentialsPath() {
+			source = sourceCredentials
+		}
+		//keys in the credentials file take precedence
+		if _, ok := effective[setting.Name]; ok {
+			continue
+		}
+		effective[setting.Name] = len(detail.Effective)
+		detail.Effective = append(detail.Effective, effectiveSetting{setting.Name, setting.Value, source})
+	}
+
+	for _, override := range settingEnvOverrides {
+		for _, env := range override.envs {
+			value, ok := os.LookupEnv(env)
+			if !ok || value == "" && !override.allowEmpty {
+				continue
+			}
+			overridden := effectiveSetting{override.key, maskSetting(override.key, value), env}
+			if i, ok := effective[override.key]; ok {
+				detail.Effective[i] = overridden
+			} else {
+				effective[override.key] = len(detail.Effective)
+				detail.Effective = append(detail.Effective, overridden)
+			}
+			break
+		}
+	}
+	return detail
+} //profileDetails
+
+//writeProfileDetail writes the details of a Profile as table or json
+func writeProfileDetail(w io.Writer, output string, detail profileDetail) error {
+	if output == outputJson {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(detail)
+	}
+
+	active := "no"
+	if detail.IsShellActive {
+		active = "yes (AWS_PROFILE)"
+	} else if detail.IsActive {
+		active = "yes (default)"
+	}
+
+	fmt.Fprintf(w, "Profile:  %s\n", detail.Name)
+	fmt.Fprintf(w, "Kind:     %s\n", detail.Kind)
+	fmt.Fprintf(w, "Active:   %s\n", active)
+	if detail.Expiration != "" {
+		expiration, _ := time.Parse(time.RFC3339, detail.Expiration)
+		fmt.Fprintf(w, "Expires:  %s\n", formatExpiration(expiration))
+	}
+
+	//consecutive keys of the same section are written as one block
+	for start := 0; start < len(detail.Settings); {
+		end := start + 1
+		for end < len(detail.Settings) && sameSection(detail.Settings[start], detail.Settings[end]) {
+			end++
+		}
+		first := detail.Settings[start]
+		if first.Inherited {
+			fmt.Fprintf(w, "\nInherited from [%s] in %s\n", first.Section, first.File)
+		} else {
+			fmt.Fprintf(w, "\n[%s] in %s\n", first.Section, first.File)
+		}
+		printSettingDetails(w, detail.Settings[start:end], "")
+		start = end
+	}
+
+	if len(detail.Effective) > 0 {
+		fmt.Fprintf(w, "\nEffective values:\n")
+		nameLength, valueLength := 0, 0
+		for _, setting := range detail.Effective {
+			if len(setting.Name) > nameLength {
+				nameLength = len(setting.Name)
+			}
+			if len(setting.Value) > valueLength {
+				valueLength = len(setting.Value)
+			}
+		}
+		for _, setting := range detail.Effective {
+			fmt.Fprintf(w, "  %-*s = %-*s  %s\n", nameLength, setting.Name, valueLength, setting.Value, setting.Source)
+		}
+	}
+	return nil
+} //writeProfileDetail
+
+func sameSection(a settingDetail, b settingDetail) bool {
+	return a.File == b.File && a.Section == b.Section && a.Inherited == b.Inherited
+} //sameSection
+
+//printSettingDetails writes aligned key = value lines prefixed with their line number,
+//nested sub-sections are indented below their key
+func printSettingDetails(w io.Writer, settings []settingDetail, indent string) {
+	nameLength := 0
+	for _, setting := range settings {
+		if len(setting.Name) > nameLength {
+			nameLength = len(setting.Name)
+		}
+	}
+	for _, setting := range settings {
+		line := ""
+		if setting.Line > 0 {
+			line = fmt.Sprint(setting.Line)
+		}
+		text := fmt.Sprintf("%5s  %s%-*s = %s", line, indent, nameLength, setting.Name, setting.Value)
+		fmt.Fprintln(w, strings.TrimRight(text, " "))
+		if len(setting.SubSettings) > 0 {
+			printSettingDetails(w, setting.SubSettings, indent+"  ")
+		}
+	}
+} //printSettingDetails
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/BernhardLenz/awsenv/store"
+	"os"
+	"testing"
+)
+
+//setupShowTest loads the all_settings files and unsets the variables overriding keys of the profiles
+func setupShowTest(t *testing.T) *store.Store {
+	for _, override := range settingEnvOverrides {
+		for _, env := range override.envs {
+			if value, ok := os.LookupEnv(env); ok {
+				os.Unsetenv(env)
+				t.Cleanup(func() { os.Setenv(env, value) })
+			}
+		}
+	}
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/all_settings_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/all_settings_config")
+	return loadTestStore(t)
+} //setupShowTest
+
+func TestShowProfile(t *testing.T) {
+	st := setupShowTest(t)
+
+	var buf bytes.Buffer
+	if err := writeProfileDetail(&buf, outputTable, profileDetails(st, testProfile(t, st, "prod"))); err != nil {
+		t.Fatalf("TestShowProfile: writeProfileDetail failed: %v", err)
+	}
+
+	expected := `Profile:  prod
+Kind:     static
+Active:   no
+
+[prod] in ./testdata/all_settings_credentials
+    6  aws_access_key_id     = ****************7891
+    7  aws_secret_access_key = ...*************7891
+    8  aws_session_token     = **ken1
+
+[profile prod] in ./testdata/all_settings_config
+    6  region       = eu-central-1
+    7  ca_bundle    = /etc/ssl/certs/corp.pem
+    8  endpoint_url = https://proxy.example.com
+    9  retry_mode   = adaptive
+   10  max_attempts = 5
+   11  cli_pager    = less
+   12  s3           =
+   13    max_concurrent_requests = 20
+   14    addressing_style        = path
+
+Inherited from [default] in ./testdata/all_settings_config
+    3  output = json
+
+Effective values:
+  aws_access_key_id     = ****************7891       credentials
+  aws_secret_access_key = ...*************7891       credentials
+  aws_session_token     = **ken1                     credentials
+  region                = eu-central-1               config
+  ca_bundle             = /etc/ssl/certs/corp.pem    config
+  endpoint_url          = https://proxy.example.com  config
+  retry_mode            = adaptive                   config
+  max_attempts          = 5                          config
+  cli_pager             = less                       config
+  output                = json                       inherited
+`
+	if buf.String() != expected {
+		t.Errorf("TestShowProfile: output is not \n%s\n: \n%s", expected, buf.String())
+		t.Fail()
+	}
+} //TestShowProfile
+
+func TestShowEnvOverrides(t *testing.T) {
+	st := setupShowTest(t)
+	os.Setenv("AWS_DEFAULT_REGION", "us-west-1")
+	os.Setenv("AWS_REGION", "eu-west-1")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "overriddensecretoverriddensecret12345678")
+	os.Setenv("AWS_PAGER", "")
+	os.Setenv("AWS_CA_BUNDLE", "")
+	defer os.Unsetenv("AWS_DEFAULT_REGION")
+	defer os.Unsetenv("AWS_REGION")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	defer os.Unsetenv("AWS_PAGER")
+	defer os.Unsetenv("AWS_CA_BUNDLE")
+
+	detail := profileDetails(st, testProfile(t, st, "admin"))
+
+	effective := make(map[string]effectiveSetting)
+	for _, setting := range detail.Effective {
+		effective[setting.Name] = setting
+	}
+
+	//AWS_REGION takes precedence over AWS_DEFAULT_REGION and the inherited region
+	if effective["region"].Value != "eu-west-1" || effective["region"].Source != "AWS_REGION" {
+		t.Errorf("TestShowEnvOverrides: region is not overridden by AWS_REGION: %v", effective["region"])
+		t.Fail()
+	}
+
+	//variables can override keys the Profile doesn't set, secrets stay masked
+	if secret := effective["aws_secret_access_key"]; secret.Value != "...*************5678" || secret.Source != "AWS_SECRET_ACCESS_KEY" {
+		t.Errorf("TestShowEnvOverrides: aws_secret_access_key is not overridden and masked: %v", secret)
+		t.Fail()
+	}
+
+	//empty variables are ignored, except AWS_PAGER which disables the pager
+	if _, ok := effective["ca_bundle"]; ok {
+		t.Errorf("TestShowEnvOverrides: empty AWS_CA_BUNDLE has been used: %v", effective["ca_bundle"])
+		t.Fail()
+	}
+	if pager, ok := effective["cli_pager"]; !ok || pager.Value != "" || pager.Source != "AWS_PAGER" {
+		t.Errorf("TestShowEnvOverrides: empty AWS_PAGER did not override cli_pager: %v", pager)
+		t.Fail()
+	}
+
+	if effective["output"].Value != "json" || effective["output"].Source != sourceInherited {
+		t.Errorf("TestShowEnvOverrides: output is not inherited from the default config: %v", effective["output"])
+		t.Fail()
+	}
+} //TestShowEnvOverrides
+
+func TestShowJson(t *testing.T) {
+	st := setupShowTest(t)
+
+	var buf bytes.Buffer
+	if err := writeProfileDetail(&buf, outputJson, profileDetails(st, testProfile(t, st, "prod"))); err != nil {
+		t.Fatalf("TestShowJson: writeProfileDetail failed: %v", err)
+	}
+
+	var detail profileDetail
+	if err := json.Unmarshal(buf.Bytes(), &detail); err != nil {
+		t.Fatalf("TestShowJson: output is not valid json: %v \n%s", err, buf.String())
+	}
+
+	s3 := detail.Settings[len(detail.Settings)-2]
+	if s3.Name != "s3" || s3.Section != "profile prod" || s3.Line != 12 || len(s3.SubSettings) != 2 || s3.SubSettings[1].Line != 14 {
+		t.Errorf("TestShowJson: s3 sub-section of prod is not in the json output: %v", s3)
+		t.Fail()
+	}
+
+	output := detail.Settings[len(detail.Settings)-1]
+	if output.Name != "output" || !output.Inherited || output.Section != "default" || output.Line != 3 {
+		t.Errorf("TestShowJson: inherited output is not in the json output: %v", output)
+		t.Fail()
+	}
+
+	if bytes.Contains(buf.Bytes(), []byte("1234567890123456789012345678901234567891")) {
+		t.Errorf("TestShowJson: secret is contained in the json output: %s", buf.String())
+		t.Fail()
+	}
+} //TestShowJson
+
+func TestLookupProfile(t *testing.T) {
+	st := setupShowTest(t)
+
+	//the default section matches no other Profile here, but can be shown in any case
+	if profile, ok := lookupProfile(st, "default"); !ok || profile.SettingValue("output") != "json" {
+		t.Errorf("TestLookupProfile: default Profile not found: %v", profile)
+		t.Fail()
+	}
+
+	if profile, ok := lookupProfile(st, "web"); !ok || profile.Kind != "web-identity" {
+		t.Errorf("TestLookupProfile: web Profile not found or not of kind web-identity: %v", profile)
+		t.Fail()
+	}
+
+	if _, ok := lookupProfile(st, "missing"); ok {
+		t.Errorf("TestLookupProfile: missing Profile found")
+		t.Fail()
+	}
+} //TestLookupProfile
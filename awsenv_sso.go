@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//AWSENV_SSO_OIDC_ENDPOINT and AWSENV_SSO_PORTAL_ENDPOINT override the IAM Identity Center endpoints,
+//e.g. to run against a local stub server
+const (
+	ssoOidcEndpointEnv   = "AWSENV_SSO_OIDC_ENDPOINT"
+	ssoPortalEndpointEnv = "AWSENV_SSO_PORTAL_ENDPOINT"
+)
+
+//grant type of the OIDC device authorization flow
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+//scope of sso-sessions which don't set sso_registration_scopes
+const defaultSsoScope = "sso:account:access"
+
+//method pointer which can be changed during test case execution
+var waitForDeviceAuthorization = func(interval time.Duration) {
+	time.Sleep(interval)
+}
+
+type registerClientResponse struct {
+	ClientId              string `json:"clientId"`
+	ClientSecret          string `json:"clientSecret"`
+	ClientSecretExpiresAt int64  `json:"clientSecretExpiresAt"`
+}
+
+type deviceAuthorizationResponse struct {
+	DeviceCode              string `json:"deviceCode"`
+	UserCode                string `json:"userCode"`
+	VerificationUri         string `json:"verificationUri"`
+	VerificationUriComplete string `json:"verificationUriComplete"`
+	ExpiresIn               int    `json:"expiresIn"`
+	Interval                int    `json:"interval"`
+}
+
+type createTokenResponse struct {
+	AccessToken  string `json:"accessToken"`
+	ExpiresIn    int    `json:"expiresIn"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+type ssoAccount struct {
+	AccountId    string `json:"accountId"`
+	AccountName  string `json:"accountName"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+type listAccountsResponse struct {
+	AccountList []ssoAccount `json:"accountList"`
+	NextToken   string       `json:"nextToken"`
+}
+
+type ssoRole struct {
+	RoleName  string `json:"roleName"`
+	AccountId string `json:"accountId"`
+}
+
+type listAccountRolesResponse struct {
+	RoleList  []ssoRole `json:"roleList"`
+	NextToken string    `json:"nextToken"`
+}
+
+//OIDC returns errors as {"error": ..., "error_description": ...}, the portal as {"message": ...}
+//with the code in the x-amzn-ErrorType header
+type jsonErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	Message          string `json:"message"`
+}
+
+func ssoOidcEndpoint(region string) string {
+	if endpoint := os.Getenv(ssoOidcEndpointEnv); endpoint != "" {
+		return endpoint
+	}
+	return "https://oidc." + region + ".amazonaws.com"
+} //ssoOidcEndpoint
+
+func ssoPortalEndpoint(region string) string {
+	if endpoint := os.Getenv(ssoPortalEndpointEnv); endpoint != "" {
+		return endpoint
+	}
+	return "https://portal.sso." + region + ".amazonaws.com"
+} //ssoPortalEndpoint
+
+//callJsonApi sends a request to the unsigned json apis of IAM Identity Center and decodes the response into result
+func callJsonApi(service string, action string, req *http.Request, result interface{}) error {
+	verbosef("Calling %s %s at %s", service, action, req.URL)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	verbosef("%s %s returned status %d", service, action, resp.StatusCode)
+
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse jsonErrorResponse
+		_ = json.Unmarshal(responseBody, &errorResponse)
+		apiErr := &ApiError{service, action, resp.StatusCode, errorResponse.Error, errorResponse.ErrorDescription}
+		if apiErr.Code == "" {
+			apiErr.Code = strings.SplitN(resp.Header.Get("x-amzn-ErrorType"), ":", 2)[0]
+			apiErr.Message = errorResponse.Message
+		}
+		return apiErr
+	}
+	return json.Unmarshal(responseBody, result)
+} //callJsonApi
+
+//callOidc posts a json request to the OIDC api
+func callOidc(region string, action string, path string, input interface{}, result interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", ssoOidcEndpoint(region)+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return callJsonApi("SSO OIDC", action, req, result)
+} //callOidc
+
+//callPortal gets a resource of the portal api with the access token of a login
+func callPortal(region string, action string, path string, params url.Values, accessToken string, result interface{}) error {
+	req, err := http.NewRequest("GET", ssoPortalEndpoint(region)+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-amz-sso_bearer_token", accessToken)
+	return callJsonApi("SSO", action, req, result)
+} //callPortal
+
+//ssoLogin runs the OIDC device authorization flow: awsenv is registered as client, the user confirms
+//the code in the browser while awsenv polls for the token, which is written to ~/.aws/sso/cache where
+//the aws cli and sdks find it. The client registration of the previous login is reused until it expires.
+func ssoLogin(login store.SsoLogin, out io.Writer) (store.SsoToken, error) {
+	token, _ := store.ReadSsoToken(login)
+	token.StartUrl = login.StartUrl
+	token.Region = login.Region
+
+	if token.ClientId == "" || time.Until(token.RegistrationExpiresAt) < time.Hour {
+		input := map[string]interface{}{"clientName": "awsenv", "clientType": "public"}
+		if login.SessionName != "" {
+			scopes := login.Scopes
+			if len(scopes) == 0 {
+				scopes = []string{defaultSsoScope}
+			}
+			input["scopes"] = scopes
+		}
+		var client registerClientResponse
+		if err := callOidc(login.Region, "RegisterClient", "/client/register", input, &client); err != nil {
+			return store.SsoToken{}, err
+		}
+		token.ClientId = client.ClientId
+		token.ClientSecret = client.ClientSecret
+		token.RegistrationExpiresAt = time.Unix(client.ClientSecretExpiresAt, 0).UTC()
+	}
+
+	var authorization deviceAuthorizationResponse
+	input := map[string]string{"clientId": token.ClientId, "clientSecret": token.ClientSecret, "startUrl": login.StartUrl}
+	if err := callOidc(login.Region, "StartDeviceAuthorization", "/device_authorization", input, &authorization); err != nil {
+		return store.SsoToken{}, err
+	}
+
+	verificationUri := authorization.VerificationUriComplete
+	if verificationUri == "" {
+		verificationUri = authorization.VerificationUri
+	}
+	fmt.Fprintf(out, "Open %s in a browser and confirm the code %s\n", verificationUri, authorization.UserCode)
+
+	interval := time.Duration(authorization.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(authorization.ExpiresIn) * time.Second)
+	for {
+		var created createTokenResponse
+		input := map[string]string{
+			"clientId":     token.ClientId,
+			"clientSecret": token.ClientSecret,
+			"grantType":    deviceCodeGrantType,
+			"deviceCode":   authorization.DeviceCode,
+		}
+		err := callOidc(login.Region, "CreateToken", "/token", input, &created)
+		if err == nil {
+			token.AccessToken = created.AccessToken
+			token.ExpiresAt = time.Now().Add(time.Duration(created.ExpiresIn) * time.Second).UTC().Truncate(time.Second)
+			token.RefreshToken = created.RefreshToken
+			break
+		}
+
+		apiErr, ok := err.(*ApiError)
+		if !ok || (apiErr.Code != "authorization_pending" && apiErr.Code != "slow_down") {
+			return store.SsoToken{}, err
+		}
+		if apiErr.Code == "slow_down" {
+			interval += 5 * time.Second
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return store.SsoToken{}, fmt.Errorf("the code %s has not been confirmed in time", authorization.UserCode)
+		}
+		waitForDeviceAuthorization(interval)
+	}
+
+	if err := store.WriteSsoToken(login, token); err != nil {
+		return store.SsoToken{}, fmt.Errorf("failed to write the token to %s: %w", store.SsoCacheDir(), err)
+	}
+	return token, nil
+} //ssoLogin
+
+//listSsoRoles returns the roles of all accounts the token gives access to
+func listSsoRoles(login store.SsoLogin, token store.SsoToken) ([]ssoAccount, map[string][]ssoRole, error) {
+	accounts := make([]ssoAccount, 0)
+	roles := make(map[string][]ssoRole)
+
+	params := url.Values{"max_result": {"100"}}
+	for {
+		var response listAccountsResponse
+		if err := callPortal(login.Region, "ListAccounts", "/assignment/accounts", params, token.AccessToken, &response); err != nil {
+			return nil, nil, err
+		}
+		accounts = append(accounts, response.AccountList...)
+		if response.NextToken == "" {
+			break
+		}
+		params.Set("next_token", response.NextToken)
+	}
+
+	for _, account := range accounts {
+		params := url.Values{"account_id": {account.AccountId}, "max_result": {"100"}}
+		for {
+			var response listAccountRolesResponse
+			if err := callPortal(login.Region, "ListAccountRoles", "/assignment/roles", params, token.AccessToken, &response); err != nil {
+				return nil, nil, err
+			}
+			roles[account.AccountId] = append(roles[account.AccountId], response.RoleList...)
+			if response.NextToken == "" {
+				break
+			}
+			params.Set("next_token", response.NextToken)
+		}
+	}
+	return accounts, roles, nil
+} //listSsoRoles
+
+//runs of characters which are replaced by a single - in generated Profile names
+var invalidProfileNameCharacters = regexp.MustCompile(`[^a-z0-9_.]+`)
+
+//ssoProfileName generates the name of a synced Profile from the account name and role, e.g. prod-readonly
+func ssoProfileName(account ssoAccount, roleName string) string {
+	name := account.AccountName
+	if name == "" {
+		name = account.AccountId
+	}
+	name = invalidProfileNameCharacters.ReplaceAllString(strings.ToLower(name+"-"+roleName), "-")
+	return strings.Trim(name, "-")
+} //ssoProfileName
+
+//ssoSync creates a Profile for every role the token of the sso-session gives access to. Roles which already
+//have a Profile are skipped, as are generated names which are taken by other profiles.
+//The names of the created profiles are returned. The config file is not saved.
+func ssoSync(st *store.Store, sessionName string, region string) ([]string, error) {
+	login, err := st.SsoLogin(sessionName)
+	if err != nil {
+		return nil, err
+	}
+	if login.SessionName != sessionName {
+		return nil, fmt.Errorf("'%s' is not an sso-session", sessionName)
+	}
+	token, ok := store.ReadSsoToken(login)
+	if !ok || !token.IsValid() {
+		return nil, fmt.Errorf("no valid token found for sso-session '%s'. Run 'awsenv sso login %s' first", sessionName, sessionName)
+	}
+
+	accounts, roles, err := listSsoRoles(login, token)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]string, 0)
+	for _, account := range accounts {
+		for _, role := range roles[account.AccountId] {
+			if existing, ok := st.SsoProfileName(sessionName, account.AccountId, role.RoleName); ok {
+				verbosef("Role %s of account %s already has Profile '%s'", role.RoleName, account.AccountId, existing)
+				continue
+			}
+			name := ssoProfileName(account, role.RoleName)
+			err := st.AddSsoProfile(name, sessionName, account.AccountId, role.RoleName, region)
+			if errors.Is(err, store.ErrProfileExists) {
+				fmt.Fprintf(os.Stderr, "WARNING: Profile '%s' already exists, role %s of account %s is skipped.\n", name, role.RoleName, account.AccountId)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			created = append(created, name)
+		}
+	}
+	return created, nil
+} //ssoSync
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+//ssoStub is a local IAM Identity Center stand-in for the OIDC and portal apis. The first CreateToken call
+//is answered with authorization_pending like while the user hasn't confirmed the code yet.
+type ssoStub struct {
+	paths   []string
+	scopes  []string
+	pending bool
+}
+
+func newSsoStub(t *testing.T) *ssoStub {
+	stub := &ssoStub{pending: true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.paths = append(stub.paths, r.URL.Path)
+		var input map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+
+		switch r.URL.Path {
+		case "/client/register":
+			stub.scopes = nil
+			if scopes, ok := input["scopes"].([]interface{}); ok {
+				for _, scope := range scopes {
+					stub.scopes = append(stub.scopes, fmt.Sprint(scope))
+				}
+			}
+			fmt.Fprintf(w, `{"clientId":"client1","clientSecret":"clientsecret1","clientSecretExpiresAt":%d}`, time.Now().Add(90*24*time.Hour).Unix())
+		case "/device_authorization":
+			fmt.Fprint(w, `{"deviceCode":"device1","userCode":"ABCD-EFGH","verificationUri":"https://device.sso.example.com/",
+"verificationUriComplete":"https://device.sso.example.com/?user_code=ABCD-EFGH","expiresIn":600,"interval":1}`)
+		case "/token":
+			if input["deviceCode"] != "device1" || input["grantType"] != deviceCodeGrantType {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_grant","error_description":"unexpected device code"}`)
+				return
+			}
+			if stub.pending {
+				stub.pending = false
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"authorization_pending"}`)
+				return
+			}
+			fmt.Fprint(w, `{"accessToken":"token1","tokenType":"Bearer","expiresIn":28800,"refreshToken":"refresh1"}`)
+		case "/assignment/accounts":
+			if r.Header.Get("x-amz-sso_bearer_token") != "token1" {
+				w.Header().Set("x-amzn-ErrorType", "UnauthorizedException:http://internal.amazon.com/coral/")
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"message":"Session token not found or invalid"}`)
+				return
+			}
+			//two pages
+			if r.URL.Query().Get("next_token") == "" {
+				fmt.Fprint(w, `{"accountList":[{"accountId":"111111111111","accountName":"Dev","emailAddress":"dev@example.com"}],"nextToken":"page2"}`)
+			} else {
+				fmt.Fprint(w, `{"accountList":[{"accountId":"333333333333","accountName":"Prod","emailAddress":"prod@example.com"}]}`)
+			}
+		case "/assignment/roles":
+			accountId := r.URL.Query().Get("account_id")
+			fmt.Fprintf(w, `{"roleList":[{"roleName":"ReadOnly","accountId":"%[1]s"},{"roleName":"Admin","accountId":"%[1]s"}]}`, accountId)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	cacheDir := t.TempDir()
+	origSsoCacheDir := store.SsoCacheDir
+	origWaitForDeviceAuthorization := waitForDeviceAuthorization
+	t.Cleanup(func() {
+		server.Close()
+		store.SsoCacheDir = origSsoCacheDir
+		waitForDeviceAuthorization = origWaitForDeviceAuthorization
+		os.Unsetenv(ssoOidcEndpointEnv)
+		os.Unsetenv(ssoPortalEndpointEnv)
+	})
+	store.SsoCacheDir = func() string { return cacheDir }
+	waitForDeviceAuthorization = func(interval time.Duration) {}
+	os.Setenv(ssoOidcEndpointEnv, server.URL)
+	os.Setenv(ssoPortalEndpointEnv, server.URL)
+	return stub
+} //newSsoStub
+
+//setupSsoTest copies the sso config into a temporary directory as sync saves it
+func setupSsoTest(t *testing.T) string {
+	configPath := copyTestdata(t, "sso_config", 0600)
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/empty_file")
+	os.Setenv("AWS_CONFIG_FILE", configPath)
+	return configPath
+} //setupSsoTest
+
+func TestSsoLogin(t *testing.T) {
+	stub := newSsoStub(t)
+	setupSsoTest(t)
+	st := loadTestStore(t)
+
+	//a Profile logs in via its sso-session
+	login, err := st.SsoLogin("sso")
+	if err != nil {
+		t.Fatalf("TestSsoLogin: SsoLogin failed: %v", err)
+	}
+	var out strings.Builder
+	if _, err := ssoLogin(login, &out); err != nil {
+		t.Fatalf("TestSsoLogin: ssoLogin failed: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "https://device.sso.example.com/?user_code=ABCD-EFGH") || !strings.Contains(out.String(), "ABCD-EFGH") {
+		t.Errorf("TestSsoLogin: verification uri and code not shown: %s", out.String())
+		t.Fail()
+	}
+	if len(stub.scopes) != 1 || stub.scopes[0] != "sso:account:access" {
+		t.Errorf("TestSsoLogin: unexpected scopes of the client registration: %v", stub.scopes)
+		t.Fail()
+	}
+
+	//same file name as the aws cli uses
+	sum := sha1.Sum([]byte("my-sso"))
+	content, err := ioutil.ReadFile(filepath.Join(store.SsoCacheDir(), hex.EncodeToString(sum[:])+".json"))
+	if err != nil {
+		t.Fatalf("TestSsoLogin: token file not written: %v", err)
+	}
+	var token map[string]string
+	_ = json.Unmarshal(content, &token)
+	expiresAt, err := time.Parse(time.RFC3339, token["expiresAt"])
+	if token["accessToken"] != "token1" || token["startUrl"] != "https://my-sso-portal.awsapps.com/start" ||
+		token["region"] != "us-east-1" || err != nil || expiresAt.Before(time.Now().Add(7*time.Hour)) {
+		t.Errorf("TestSsoLogin: unexpected token file: %s", content)
+		t.Fail()
+	}
+
+	//the client registration is reused
+	stub.paths = nil
+	stub.pending = false
+	if _, err := ssoLogin(login, &out); err != nil {
+		t.Fatalf("TestSsoLogin: second ssoLogin failed: %v", err)
+	}
+	if strings.Join(stub.paths, " ") != "/device_authorization /token" {
+		t.Errorf("TestSsoLogin: unexpected calls of the second login: %v", stub.paths)
+		t.Fail()
+	}
+} //TestSsoLogin
+
+func TestSsoLoginLegacy(t *testing.T) {
+	stub := newSsoStub(t)
+	setupSsoTest(t)
+	st := loadTestStore(t)
+
+	login, err := st.SsoLogin("legacy")
+	if err != nil {
+		t.Fatalf("TestSsoLoginLegacy: SsoLogin failed: %v", err)
+	}
+	if login.SessionName != "" || login.Region != "eu-west-1" {
+		t.Errorf("TestSsoLoginLegacy: unexpected login: %v", login)
+		t.Fail()
+	}
+	if _, err := ssoLogin(login, ioutil.Discard); err != nil {
+		t.Fatalf("TestSsoLoginLegacy: ssoLogin failed: %v", err)
+	}
+
+	//legacy profiles are registered without scopes and cached by start url
+	sum := sha1.Sum([]byte("https://legacy.awsapps.com/start"))
+	if _, err := os.Stat(filepath.Join(store.SsoCacheDir(), hex.EncodeToString(sum[:])+".json")); err != nil || len(stub.scopes) != 0 {
+		t.Errorf("TestSsoLoginLegacy: unexpected scopes %v or token file: %v", stub.scopes, err)
+		t.Fail()
+	}
+
+	if _, err := st.SsoLogin("prod-admin"); exitCode(err) != exitProfileNotFound {
+		t.Errorf("TestSsoLoginLegacy: Profile not found not returned for a Profile without sso: %v", err)
+		t.Fail()
+	}
+} //TestSsoLoginLegacy
+
+func TestSsoSync(t *testing.T) {
+	newSsoStub(t)
+	configPath := setupSsoTest(t)
+	st := loadTestStore(t)
+
+	if _, err := ssoSync(st, "my-sso", ""); err == nil {
+		t.Errorf("TestSsoSync: no error returned without login")
+		t.Fail()
+	}
+
+	login, _ := st.SsoLogin("my-sso")
+	if _, err := ssoLogin(login, ioutil.Discard); err != nil {
+		t.Fatalf("TestSsoSync: ssoLogin failed: %v", err)
+	}
+
+	created, err := ssoSync(st, "my-sso", "eu-west-1")
+	if err != nil {
+		t.Fatalf("TestSsoSync: ssoSync failed: %v", err)
+	}
+	if err := st.Save(); err != nil {
+		t.Fatalf("TestSsoSync: Save failed: %v", err)
+	}
+
+	//Dev ReadOnly already has the sso Profile and prod-admin is taken by another Profile
+	if strings.Join(created, " ") != "dev-admin prod-readonly" {
+		t.Errorf("TestSsoSync: unexpected created profiles: %v", created)
+		t.Fail()
+	}
+
+	st = loadTestStore(t)
+	profile := testProfile(t, st, "prod-readonly")
+	config, _ := st.Config("prod-readonly")
+	if profile.Kind != store.KindSso || profile.Region != "eu-west-1" || config.SsoSession != "my-sso" ||
+		config.SsoAccountId != "333333333333" || config.SsoRoleName != "ReadOnly" {
+		t.Errorf("TestSsoSync: unexpected prod-readonly Profile %v with config %v", profile, config)
+		t.Fail()
+	}
+
+	//a second sync doesn't add anything
+	created, err = ssoSync(st, "my-sso", "")
+	if err != nil || len(created) != 0 {
+		t.Errorf("TestSsoSync: second sync created %v: %v", created, err)
+		t.Fail()
+	}
+
+	if content, _ := ioutil.ReadFile(configPath); !strings.Contains(string(content), "[profile dev-admin]") {
+		t.Errorf("TestSsoSync: profiles not written to the config file:\n%s", content)
+		t.Fail()
+	}
+} //TestSsoSync
+
+func TestSsoProfileName(t *testing.T) {
+	tests := []struct {
+		account  ssoAccount
+		roleName string
+		expected string
+	}{
+		{ssoAccount{AccountId: "111111111111", AccountName: "Dev"}, "ReadOnly", "dev-readonly"},
+		{ssoAccount{AccountId: "111111111111", AccountName: "My Team (Sandbox)"}, "AWSAdministratorAccess", "my-team-sandbox-awsadministratoraccess"},
+		{ssoAccount{AccountId: "111111111111"}, "Admin", "111111111111-admin"},
+	}
+	for _, test := range tests {
+		if name := ssoProfileName(test.account, test.roleName); name != test.expected {
+			t.Errorf("TestSsoProfileName: name of %v %s is not '%s': %s", test.account, test.roleName, test.expected, name)
+			t.Fail()
+		}
+	}
+} //TestSsoProfileName
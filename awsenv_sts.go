@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const stsApiVersion = "2011-06-15"
+
+//AWSENV_STS_ENDPOINT overrides the STS endpoint, e.g. to run against a local stub server
+const stsEndpointEnv = "AWSENV_STS_ENDPOINT"
+
+type stsCredentials struct {
+	AccessKeyId     string `xml:"AccessKeyId"`
+	SecretAccessKey string `xml:"SecretAccessKey"`
+	SessionToken    string `xml:"SessionToken"`
+	Expiration      string `xml:"Expiration"`
+}
+
+type assumeRoleResponse struct {
+	Credentials     stsCredentials        `xml:"AssumeRoleResult>Credentials"`
+	AssumedRoleUser store.AssumedRoleUser `xml:"AssumeRoleResult>AssumedRoleUser"`
+}
+
+type getSessionTokenResponse struct {
+	Credentials stsCredentials `xml:"GetSessionTokenResult>Credentials"`
+}
+
+type getCallerIdentityResponse struct {
+	Account string `xml:"GetCallerIdentityResult>Account"`
+	Arn     string `xml:"GetCallerIdentityResult>Arn"`
+	UserId  string `xml:"GetCallerIdentityResult>UserId"`
+}
+
+//STS and IAM return errors in the same format
+type apiErrorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+//ApiError is returned for error responses of the STS and IAM api
+type ApiError struct {
+	Service    string
+	Action     string
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("%s %s failed with status %d: %s: %s", e.Service, e.Action, e.StatusCode, e.Code, e.Message)
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+//stsEndpoint returns the regional STS endpoint or the global one if no region is configured
+func stsEndpoint(region string) string {
+	if endpoint := os.Getenv(stsEndpointEnv); endpoint != "" {
+		return endpoint
+	}
+	if region == "" {
+		return "https://sts.amazonaws.com"
+	}
+	return "https://sts." + region + ".amazonaws.com"
+} //stsEndpoint
+
+//callSts sends a signed STS query api request and decodes the xml response into result
+func callSts(creds store.Credentials, region string, params url.Values, result interface{}) error {
+	signingRegion := region
+	if signingRegion == "" {
+		signingRegion = "us-east-1"
+	}
+	params.Set("Version", stsApiVersion)
+	return callQueryApi("STS", stsEndpoint(region), signingRegion, creds, params, result)
+} //callSts
+
+//callQueryApi sends a signed query api request of STS or IAM and decodes the xml response into result
+func callQueryApi(service string, endpoint string, signingRegion string, creds store.Credentials, params url.Values, result interface{}) error {
+	body := []byte(params.Encode())
+
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signRequest(req, body, creds, signingRegion, strings.ToLower(service), time.Now())
+
+	verbosef("Calling %s %s at %s", service, params.Get("Action"), req.URL)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	verbosef("%s %s returned status %d", service, params.Get("Action"), resp.StatusCode)
+
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse apiErrorResponse
+		_ = xml.Unmarshal(responseBody, &errorResponse)
+		return &ApiError{service, params.Get("Action"), resp.StatusCode, errorResponse.Code, errorResponse.Message}
+	}
+
+	if result == nil {
+		return nil
+	}
+	return xml.Unmarshal(responseBody, result)
+} //callQueryApi
+
+func (c stsCredentials) toCredentials() (store.Credentials, error) {
+	expiration, err := time.Parse(time.RFC3339, c.Expiration)
+	if err != nil {
+		return store.Credentials{}, fmt.Errorf("invalid expiration '%s' in STS response: %v", c.Expiration, err)
+	}
+	return store.Credentials{AccessKeyId: c.AccessKeyId, SecretAccessKey: c.SecretAccessKey, SessionToken: c.SessionToken, Expiration: expiration}, nil
+} //toCredentials
+
+type assumeRoleInput struct {
+	roleArn         string
+	roleSessionName string
+	externalId      string
+	serialNumber    string
+	tokenCode       string
+	durationSeconds int
+}
+
+func assumeRole(creds store.Credentials, region string, input assumeRoleInput) (store.Credentials, store.AssumedRoleUser, error) {
+	params := url.Values{}
+	params.Set("Action", "AssumeRole")
+	params.Set("RoleArn", input.roleArn)
+	params.Set("RoleSessionName", input.roleSessionName)
+	if input.externalId != "" {
+		params.Set("ExternalId", input.externalId)
+	}
+	if input.serialNumber != "" {
+		params.Set("SerialNumber", input.serialNumber)
+		params.Set("TokenCode", input.tokenCode)
+	}
+	if input.durationSeconds > 0 {
+		params.Set("DurationSeconds", fmt.Sprint(input.durationSeconds))
+	}
+
+	var response assumeRoleResponse
+	if err := callSts(creds, region, params, &response); err != nil {
+		return store.Credentials{}, store.AssumedRoleUser{}, err
+	}
+	assumed, err := response.Credentials.toCredentials()
+	return assumed, response.AssumedRoleUser, err
+} //assumeRole
+
+func getSessionToken(creds store.Credentials, region string, serialNumber string, tokenCode string, durationSeconds int) (store.Credentials, error) {
+	params := url.Values{}
+	params.Set("Action", "GetSessionToken")
+	params.Set("SerialNumber", serialNumber)
+	params.Set("TokenCode", tokenCode)
+	if durationSeconds > 0 {
+		params.Set("DurationSeconds", fmt.Sprint(durationSeconds))
+	}
+
+	var response getSessionTokenResponse
+	if err := callSts(creds, region, params, &response); err != nil {
+		return store.Credentials{}, err
+	}
+	return response.Credentials.toCredentials()
+} //getSessionToken
+
+//getCallerIdentity returns the account, arn and user id of the credentials
+func getCallerIdentity(creds store.Credentials, region string) (getCallerIdentityResponse, error) {
+	params := url.Values{}
+	params.Set("Action", "GetCallerIdentity")
+
+	var response getCallerIdentityResponse
+	err := callSts(creds, region, params, &response)
+	return response, err
+} //getCallerIdentity
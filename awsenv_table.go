@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//space between two columns of the profile table
+const columnGap = "   "
+
+//escape sequences used to highlight the active rows
+const (
+	colorActive      = "\x1b[1;32m"
+	colorShellActive = "\x1b[1;36m"
+	colorReset       = "\x1b[0m"
+)
+
+type tableColumn struct {
+	name   string
+	header string
+	//the width of a column is never truncated below minWidth
+	minWidth int
+	value    func(profile store.Profile, context tableContext) string
+	truncate func(s string, width int) string
+}
+
+//tableContext holds the data besides the Profile itself which the columns are computed from
+type tableContext struct {
+	defaultConfig store.Config
+	//cached identities by access key id, only read if the identity column is shown
+	identities map[string]callerIdentity
+}
+
+//profileColumns are all columns of the list table in the default order
+var profileColumns = []tableColumn{
+	{"name", "PROFILE", 8, func(profile store.Profile, _ tableContext) string { return profile.Name }, truncateEnd},
+	{"kind", "KIND", 4, func(profile store.Profile, _ tableContext) string { return profile.Kind }, truncateEnd},
+	{"key", "AWS_ACCESS_KEY_ID", 8, func(profile store.Profile, _ tableContext) string {
+		return maskAccessKey(profile.AwsAccessKeyId, 20)
+	}, truncateStart},
+	{"account", "ACCOUNT", 7, func(profile store.Profile, _ tableContext) string {
+		account, _ := store.AccessKeyAccount(profile.AwsAccessKeyId)
+		return account
+	}, truncateEnd},
+	{"key-type", "KEY_TYPE", 8, func(profile store.Profile, _ tableContext) string { return store.AccessKeyType(profile.AwsAccessKeyId) }, truncateEnd},
+	{"region", "REGION", 8, func(profile store.Profile, context tableContext) string {
+		return inheritedValue(profile.Region, context.defaultConfig.Region)
+	}, truncateEnd},
+	{"output", "OUTPUT", 6, func(profile store.Profile, context tableContext) string {
+		return inheritedValue(profile.Output, context.defaultConfig.Output)
+	}, truncateEnd},
+	{"expiry", "EXPIRES", 7, func(profile store.Profile, _ tableContext) string { return formatExpiration(profile.Expiration) }, truncateEnd},
+}
+
+//identityColumn shows the ARN whoami has cached for the access key of a Profile. It is not part of the
+//default columns as it needs to read the cache.
+var identityColumn = tableColumn{"identity", "IDENTITY", 12, func(profile store.Profile, context tableContext) string {
+	return context.identities[profile.AwsAccessKeyId].Arn
+}, truncateStart}
+
+type tableOptions struct {
+	columns []string
+	//wide disables the truncation of columns to the terminal width
+	wide bool
+	//width of the terminal, 0 if unknown
+	width int
+	color bool
+}
+
+//inheritedValue returns the value of a Profile or the value of the default config in []
+func inheritedValue(value string, defaultValue string) string {
+	if value == "" && defaultValue != "" {
+		return "[" + defaultValue + "]"
+	}
+	return value
+} //inheritedValue
+
+//selectColumns returns the columns for a comma separated list of column names.
+//Besides the names the lower case headers such as expires or aws_access_key_id are accepted.
+func selectColumns(names []string) ([]tableColumn, error) {
+	if len(names) == 0 {
+		return profileColumns, nil
+	}
+	selected := make([]tableColumn, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, column := range append(profileColumns, identityColumn) {
+			if strings.EqualFold(name, column.name) || strings.EqualFold(name, column.header) || (name == "profile" && column.name == "name") {
+				selected = append(selected, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column '%s'! Supported columns are name, kind, key, account, key-type, region, output, expiry and identity", name)
+		}
+	}
+	return selected, nil
+} //selectColumns
+
+//parseColumns splits the value of the --columns flag
+func parseColumns(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+} //parseColumns
+
+//withIdentityColumn adds the identity column to the selected or default columns for list --identity
+func withIdentityColumn(names []string) []string {
+	if len(names) == 0 {
+		for _, column := range profileColumns {
+			names = append(names, column.name)
+		}
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, identityColumn.name) {
+			return names
+		}
+	}
+	return append(names, identityColumn.name)
+} //withIdentityColumn
+
+//renderProfileTable writes the profiles as table. The columns are sized to their content and
+//unless wide is set the widest columns are truncated until the table fits into the terminal.
+func renderProfileTable(w io.Writer, st *store.Store, profiles []store.Profile, options tableOptions) error {
+	columns, err := selectColumns(options.columns)
+	if err != nil {
+		return err
+	}
+
+	context := tableContext{defaultConfig: st.DefaultConfig()}
+	for _, column := range columns {
+		if column.name == identityColumn.name {
+			context.identities = readIdentityCache()
+		}
+	}
+	cells := make([][]string, len(profiles))
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = utf8.RuneCountInString(column.header)
+	}
+	for row, profile := range profiles {
+		cells[row] = make([]string, len(columns))
+		for i, column := range columns {
+			cells[row][i] = column.value(profile, context)
+			if length := utf8.RuneCountInString(cells[row][i]); length > widths[i] {
+				widths[i] = length
+			}
+		}
+	}
+
+	if !options.wide && options.width > 0 {
+		fitColumns(columns, widths, options.width)
+	}
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.header
+	}
+	fmt.Fprintln(w, formatRow("  ", header, columns, widths, true))
+
+	for row, profile := range profiles {
+		marker := " "
+		if profile.IsActive {
+			marker = "*"
+		}
+		if profile.IsShellActive {
+			marker += ">"
+		} else {
+			marker += " "
+		}
+		line := formatRow(marker, cells[row], columns, widths, false)
+		if options.color {
+			if profile.IsShellActive {
+				line = colorShellActive + line + colorReset
+			} else if profile.IsActive {
+				line = colorActive + line + colorReset
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+	return nil
+} //renderProfileTable
+
+//fitColumns shrinks the widest column one character at a time until the table fits into width
+//or all columns have reached their minimum width
+func fitColumns(columns []tableColumn, widths []int, width int) {
+	total := 2 + len(columnGap)*(len(columns)-1)
+	for _, w := range widths {
+		total += w
+	}
+	for total > width {
+		widest := -1
+		for i, column := range columns {
+			if widths[i] > column.minWidth && (widest == -1 || widths[i] > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest == -1 {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+} //fitColumns
+
+//formatRow pads the cells to the column widths, which like the padding of fmt count runes and not bytes.
+//Headers are always truncated at the end.
+func formatRow(marker string, cells []string, columns []tableColumn, widths []int, header bool) string {
+	var sb strings.Builder
+	sb.WriteString(marker)
+	for i, cell := range cells {
+		if i > 0 {
+			sb.WriteString(columnGap)
+		}
+		length := utf8.RuneCountInString(cell)
+		if length > widths[i] && header {
+			cell = truncateEnd(cell, widths[i])
+		} else if length > widths[i] {
+			cell = columns[i].truncate(cell, widths[i])
+		}
+		sb.WriteString(fmt.Sprintf("%-"+strconv.Itoa(widths[i])+"s", cell))
+	}
+	return strings.TrimRight(sb.String(), " ")
+} //formatRow
+
+//truncateEnd cuts off the end of s and marks it with "..."
+func truncateEnd(s string, width int) string {
+	runes := []rune(s)
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+} //truncateEnd
+
+//truncateStart cuts off the start of s so that e.g. the last characters of an access key remain visible
+func truncateStart(s string, width int) string {
+	runes := []rune(s)
+	if width <= 3 {
+		return string(runes[len(runes)-width:])
+	}
+	return "..." + string(runes[len(runes)-width+3:])
+} //truncateStart
+
+//isTerminal returns true if the file is a terminal and not redirected into a file or pipe
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+} //isTerminal
+
+//terminalWidth returns the number of columns of the terminal or 0 if unknown.
+//COLUMNS takes precedence over the size reported by the terminal.
+func terminalWidth(file *os.File) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if !isTerminal(file) {
+		return 0
+	}
+	return terminalSize(file)
+} //terminalWidth
+
+//printProfileTable prints the profiles to stdout. The table is only truncated and coloured
+//if stdout is a terminal, NO_COLOR disables the colours.
+func printProfileTable(st *store.Store, profiles []store.Profile, options tableOptions) error {
+	options.width = terminalWidth(os.Stdout)
+	options.color = options.color && isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+	if err := renderProfileTable(os.Stdout, st, profiles, options); err != nil {
+		return err
+	}
+
+	if len(profiles) > 0 {
+		fmt.Printf("\n")
+		fmt.Println("Profiles with * are active profiles. Profiles with region or output in [] are using the default config.")
+		if os.Getenv("AWS_PROFILE") != "" {
+			fmt.Println("The Profile with > is selected in the current shell by AWS_PROFILE and takes precedence over the active profiles.")
+		}
+	}
+	return nil
+} //printProfileTable
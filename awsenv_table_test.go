@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"github.com/BernhardLenz/awsenv/store"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRenderProfileTable(t *testing.T) {
+
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/config_only_profiles_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/config_only_profiles_config")
+	st := loadTestStore(t)
+
+	profile := testProfile(t, st, "prod")
+	profile.Name = "company-sandbox-eu-central"
+	profile.IsActive = true
+	rows := []store.Profile{profile, testProfile(t, st, "admin")}
+
+	var buf bytes.Buffer
+	if err := renderProfileTable(&buf, st, rows, tableOptions{columns: []string{"name", "region", "expiry"}}); err != nil {
+		t.Fatalf("TestRenderProfileTable: %v", err)
+	}
+	expected := "  PROFILE                      REGION         EXPIRES\n" +
+		"* company-sandbox-eu-central   eu-central-1\n" +
+		"  admin                        [us-east-1]\n"
+	if buf.String() != expected {
+		t.Errorf("TestRenderProfileTable: table is not\n%s\nactual:\n%s", expected, buf.String())
+		t.Fail()
+	}
+
+	buf.Reset()
+	_ = renderProfileTable(&buf, st, rows, tableOptions{columns: []string{"name", "key"}, width: 30})
+	expected = "  PROFILE        AWS_ACCESS...\n" +
+		"* company-s...   ...******7890\n" +
+		"  admin\n"
+	if buf.String() != expected {
+		t.Errorf("TestRenderProfileTable: truncated table is not\n%s\nactual:\n%s", expected, buf.String())
+		t.Fail()
+	}
+
+	//widths count runes, so multibyte names are neither split nor padded short
+	umlauts := testProfile(t, st, "admin")
+	umlauts.Name = "müller-größe-prüfung"
+	buf.Reset()
+	_ = renderProfileTable(&buf, st, []store.Profile{umlauts, testProfile(t, st, "prod")}, tableOptions{columns: []string{"name", "region"}})
+	expected = "  PROFILE                REGION\n" +
+		"  müller-größe-prüfung   [us-east-1]\n" +
+		"  prod                   eu-central-1\n"
+	if buf.String() != expected {
+		t.Errorf("TestRenderProfileTable: table with multibyte names is not\n%s\nactual:\n%s", expected, buf.String())
+		t.Fail()
+	}
+	buf.Reset()
+	_ = renderProfileTable(&buf, st, []store.Profile{umlauts}, tableOptions{columns: []string{"name", "key"}, width: 30})
+	if expected = "  müller-gr...\n"; !strings.HasSuffix(buf.String(), expected) {
+		t.Errorf("TestRenderProfileTable: truncated multibyte name is not\n%s\nactual:\n%s", expected, buf.String())
+		t.Fail()
+	}
+
+	buf.Reset()
+	_ = renderProfileTable(&buf, st, rows, tableOptions{width: 30, wide: true})
+	if !strings.Contains(buf.String(), "company-sandbox-eu-central") {
+		t.Errorf("TestRenderProfileTable: --wide truncated the table:\n%s", buf.String())
+		t.Fail()
+	}
+
+	buf.Reset()
+	_ = renderProfileTable(&buf, st, rows, tableOptions{columns: []string{"name"}, color: true})
+	if !strings.Contains(buf.String(), colorActive+"* company-sandbox-eu-central"+colorReset+"\n") || strings.Count(buf.String(), colorReset) != 1 {
+		t.Errorf("TestRenderProfileTable: only the active row should be coloured:\n%q", buf.String())
+		t.Fail()
+	}
+
+	if err := renderProfileTable(&buf, st, rows, tableOptions{columns: []string{"secret"}}); err == nil {
+		t.Errorf("TestRenderProfileTable: an unknown column didn't fail")
+		t.Fail()
+	}
+} //TestRenderProfileTable
+
+func TestAccountColumns(t *testing.T) {
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/account_keys_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/only_default_config")
+	st := loadTestStore(t)
+	rows := []store.Profile{testProfile(t, st, "example"), testProfile(t, st, "session"), testProfile(t, st, "local")}
+
+	var buf bytes.Buffer
+	if err := renderProfileTable(&buf, st, rows, tableOptions{columns: []string{"name", "account", "key_type"}}); err != nil {
+		t.Fatalf("TestAccountColumns: %v", err)
+	}
+	expected := "  PROFILE   ACCOUNT        KEY_TYPE\n" +
+		"* example   581039954779   long-term\n" +
+		"  session   609629065308   temporary\n" +
+		"  local                    other\n"
+	if buf.String() != expected {
+		t.Errorf("TestAccountColumns: table is not\n%s\nactual:\n%s", expected, buf.String())
+		t.Fail()
+	}
+} //TestAccountColumns
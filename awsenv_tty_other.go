@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package main
+
+import (
+	"os"
+)
+
+//terminalSize is unknown on this platform, the table is only truncated if COLUMNS is set
+func terminalSize(file *os.File) int {
+	return 0
+} //terminalSize
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+//shellProfileVariables selects a Profile by name only. Keys and regions exported by a previous
+//env or activate --export are removed as they would take precedence over AWS_PROFILE.
+func shellProfileVariables(profile store.Profile) []envVar {
+	return []envVar{
+		{"AWS_ACCESS_KEY_ID", ""},
+		{"AWS_SECRET_ACCESS_KEY", ""},
+		{"AWS_SESSION_TOKEN", ""},
+		{"AWS_DEFAULT_REGION", ""},
+		{"AWS_REGION", ""},
+		{"AWS_PROFILE", profile.Name},
+	}
+} //shellProfileVariables
+
+//childEnvironment returns the environment of the awsenv process with the given variables applied.
+//Variables with an empty value are removed.
+func childEnvironment(vars []envVar) []string {
+	overridden := make(map[string]bool)
+	for _, v := range vars {
+		overridden[strings.ToUpper(v.name)] = true
+	}
+
+	env := make([]string, 0)
+	for _, entry := range os.Environ() {
+		name := strings.SplitN(entry, "=", 2)[0]
+		if !overridden[strings.ToUpper(name)] {
+			env = append(env, entry)
+		}
+	}
+	for _, v := range vars {
+		if v.value != "" {
+			env = append(env, v.name+"="+v.value)
+		}
+	}
+	return env
+} //childEnvironment
+
+//userShell returns the interactive shell of the user
+func userShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	if runtime.GOOS == "windows" {
+		if os.Getenv("PSModulePath") != "" {
+			return "powershell.exe"
+		}
+		if comSpec := os.Getenv("ComSpec"); comSpec != "" {
+			return comSpec
+		}
+		return "cmd.exe"
+	}
+	return "/bin/sh"
+} //userShell
+
+//runSubshell starts an interactive shell using the given Profile. A non-zero exit code of the shell
+//is returned as exitStatusError.
+func runSubshell(profile store.Profile) error {
+	cmd := exec.Command(userShell())
+	cmd.Env = childEnvironment(shellProfileVariables(profile))
+	return runChild(cmd)
+} //runSubshell
+
+//runChild runs a command connected to the terminal of awsenv. A non-zero exit code of the command
+//is returned as exitStatusError.
+//Termination signals sent to awsenv are forwarded to the child. Interrupts are ignored as the terminal
+//already delivers Ctrl-C to the child, forwarding it as well would e.g. make terraform abort forcefully.
+func runChild(cmd *exec.Cmd) error {
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("Failed to run '%s': %w", cmd.Path, err)
+	}
+
+	go func() {
+		for sig := range signals {
+			if sig != os.Interrupt {
+				_ = cmd.Process.Signal(sig)
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	if err == nil {
+		return nil
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		//like shells report a child killed by a signal with 128 + the signal number
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return &exitStatusError{128 + int(status.Signal())}
+		}
+		return &exitStatusError{exitErr.ExitCode()}
+	}
+	return fmt.Errorf("Failed to run '%s': %w", cmd.Path, err)
+} //runChild
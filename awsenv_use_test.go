@@ -0,0 +1,131 @@
+package main
+
+import (
+	"github.com/BernhardLenz/awsenv/store"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestShellActiveProfile(t *testing.T) {
+	t.Cleanup(func() { os.Unsetenv("AWS_PROFILE") })
+
+	os.Setenv("AWS_PROFILE", "dev")
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/profile_sections_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/profile_sections_config")
+	st := loadTestStore(t)
+
+	profile := testProfile(t, st, "dev")
+
+	if !profile.IsShellActive {
+		t.Errorf("TestShellActiveProfile: dev Profile.IsShellActive is not true: %t ", profile.IsShellActive)
+		t.Fail()
+	}
+
+	if profile.IsActive {
+		t.Errorf("TestShellActiveProfile: dev Profile.IsActive is true: %t ", profile.IsActive)
+		t.Fail()
+	}
+
+	profile = testProfile(t, st, "prod")
+
+	if profile.IsShellActive {
+		t.Errorf("TestShellActiveProfile: prod Profile.IsShellActive is true: %t ", profile.IsShellActive)
+		t.Fail()
+	}
+
+	if !profile.IsActive {
+		t.Errorf("TestShellActiveProfile: prod Profile.IsActive is not true: %t ", profile.IsActive)
+		t.Fail()
+	}
+} //TestShellActiveProfile
+
+func TestUseExports(t *testing.T) {
+	expected := "unset AWS_ACCESS_KEY_ID\n" +
+		"unset AWS_SECRET_ACCESS_KEY\n" +
+		"unset AWS_SESSION_TOKEN\n" +
+		"unset AWS_DEFAULT_REGION\n" +
+		"unset AWS_REGION\n" +
+		"export AWS_PROFILE='dev'\n"
+
+	actual := formatExports(shellBash, shellProfileVariables(store.Profile{Name: "dev"}))
+	if actual != expected {
+		t.Errorf("TestUseExports: output is not\n%s\nactual:\n%s", expected, actual)
+		t.Fail()
+	}
+} //TestUseExports
+
+func TestChildEnvironment(t *testing.T) {
+	t.Cleanup(func() {
+		os.Unsetenv("AWS_ACCESS_KEY_ID")
+		os.Unsetenv("AWSENV_TEST_VARIABLE")
+	})
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIDPARENT")
+	os.Setenv("AWSENV_TEST_VARIABLE", "kept")
+
+	env := childEnvironment(shellProfileVariables(store.Profile{Name: "dev"}))
+	joined := "\n" + strings.Join(env, "\n") + "\n"
+
+	if strings.Contains(joined, "\nAWS_ACCESS_KEY_ID=") {
+		t.Errorf("TestChildEnvironment: AWS_ACCESS_KEY_ID of the parent has not been removed")
+		t.Fail()
+	}
+
+	if !strings.Contains(joined, "\nAWS_PROFILE=dev\n") {
+		t.Errorf("TestChildEnvironment: AWS_PROFILE=dev is missing")
+		t.Fail()
+	}
+
+	if !strings.Contains(joined, "\nAWSENV_TEST_VARIABLE=kept\n") {
+		t.Errorf("TestChildEnvironment: unrelated variables have not been kept")
+		t.Fail()
+	}
+} //TestChildEnvironment
+
+//captureStdout returns what f writes to os.Stdout
+func captureStdout(t *testing.T, f func()) string {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("captureStdout: %v", err)
+	}
+	origStdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = origStdout }()
+
+	output := make(chan string)
+	go func() {
+		content, _ := ioutil.ReadAll(reader)
+		output <- string(content)
+	}()
+	f()
+	writer.Close()
+	return <-output
+} //captureStdout
+
+func TestActivateWithoutShellProfile(t *testing.T) {
+	//activate sets the variables of the default Profile in its own process
+	variables := []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_DEFAULT_REGION", "AWS_REGION", "AWS_PROFILE"}
+	t.Cleanup(func() {
+		for _, name := range variables {
+			os.Unsetenv(name)
+		}
+	})
+	os.Unsetenv("AWS_PROFILE")
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", copyTestdata(t, "mfa_credentials", 0600))
+	os.Setenv("AWS_CONFIG_FILE", copyTestdata(t, "mfa_config", 0600))
+
+	var err error
+	output := captureStdout(t, func() { err = run([]string{"activate", "prod"}) })
+	if err != nil {
+		t.Fatalf("TestActivateWithoutShellProfile: activate failed: %v", err)
+	}
+	if !strings.Contains(output, "Activated Profile 'prod'") {
+		t.Errorf("TestActivateWithoutShellProfile: unexpected output:\n%s", output)
+		t.Fail()
+	}
+	if strings.Contains(output, "AWS_PROFILE") || strings.Contains(output, "*>") {
+		t.Errorf("TestActivateWithoutShellProfile: a Profile is listed as selected by AWS_PROFILE:\n%s", output)
+		t.Fail()
+	}
+} //TestActivateWithoutShellProfile
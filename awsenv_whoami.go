@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BernhardLenz/awsenv/store"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//method pointer which can be changed during test case execution
+var getIdentityCacheFilePath = func() string {
+	return filepath.Join(filepath.Dir(store.DefaultConfigPath()), "awsenv_identities.json")
+}
+
+//callerIdentity is the result of GetCallerIdentity as it is cached per access key id. Identities of
+//temporary credentials are kept until the credentials expire. The cache contains no secrets.
+type callerIdentity struct {
+	Account    string    `json:"account"`
+	Arn        string    `json:"arn"`
+	UserId     string    `json:"userId"`
+	CheckedAt  time.Time `json:"checkedAt"`
+	Expiration string    `json:"expiration,omitempty"`
+}
+
+//whoamiProfileName returns the Profile the aws cli would use, i.e. AWS_PROFILE or default
+func whoamiProfileName() string {
+	if profileName := os.Getenv("AWS_PROFILE"); profileName != "" {
+		return profileName
+	}
+	return store.DefaultSection
+} //whoamiProfileName
+
+//whoami calls STS GetCallerIdentity with the credentials of a static or role Profile and caches the
+//identity for the access key the call has been signed with
+func whoami(st *store.Store, profileName string) (callerIdentity, error) {
+	profile, ok := lookupProfile(st, profileName)
+	if !ok {
+		return callerIdentity{}, &store.ProfileError{Kind: store.ErrProfileNotFound, ProfileName: profileName}
+	}
+	//the default section has no kind if it matches another Profile
+	if profile.Kind == store.KindSso || profile.Kind == store.KindProcess || profile.Kind == store.KindWebIdentity {
+		return callerIdentity{}, fmt.Errorf("the credentials of Profile '%s' of kind %s can't be resolved by awsenv", profileName, profile.Kind)
+	}
+
+	creds, err := resolveCredentials(st, profileName)
+	if err != nil {
+		return callerIdentity{}, err
+	}
+	response, err := getCallerIdentity(creds, effectiveRegion(st, profile))
+	if err != nil {
+		return callerIdentity{}, err
+	}
+
+	identity := callerIdentity{
+		Account:   response.Account,
+		Arn:       response.Arn,
+		UserId:    response.UserId,
+		CheckedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if !creds.Expiration.IsZero() {
+		identity.Expiration = creds.Expiration.UTC().Format(time.RFC3339)
+	}
+	cacheIdentity(creds.AccessKeyId, identity)
+	return identity, nil
+} //whoami
+
+func printIdentity(w io.Writer, profileName string, identity callerIdentity) {
+	fmt.Fprintf(w, "Profile: %s\n", profileName)
+	fmt.Fprintf(w, "Account: %s\n", identity.Account)
+	fmt.Fprintf(w, "Arn:     %s\n", identity.Arn)
+	fmt.Fprintf(w, "UserId:  %s\n", identity.UserId)
+} //printIdentity
+
+//readIdentityCache returns the cached identities by access key id
+func readIdentityCache() map[string]callerIdentity {
+	identities := make(map[string]callerIdentity)
+	content, err := ioutil.ReadFile(getIdentityCacheFilePath())
+	if err == nil {
+		_ = json.Unmarshal(content, &identities)
+	}
+	return identities
+} //readIdentityCache
+
+//cacheIdentity records the identity of an access key for list --identity and drops the identities of
+//expired temporary credentials. Failures are only reported with --verbose as this is only a convenience.
+func cacheIdentity(accessKeyId string, identity callerIdentity) {
+	identities := readIdentityCache()
+	for key, cached := range identities {
+		if expiration, err := time.Parse(time.RFC3339, cached.Expiration); err == nil && expiration.Before(time.Now()) {
+			delete(identities, key)
+		}
+	}
+	identities[accessKeyId] = identity
+
+	content, err := json.MarshalIndent(identities, "", "  ")
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(filepath.Dir(getIdentityCacheFilePath())); err != nil {
+		return
+	}
+	if err := store.WriteFileAtomic(getIdentityCacheFilePath(), content); err != nil {
+		verbosef("Failed to cache the identity of access key %s: %v", maskAccessKey(accessKeyId, 20), err)
+	}
+} //cacheIdentity
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"github.com/BernhardLenz/awsenv/store"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//setupIdentityCache redirects the identity cache into a temporary directory
+func setupIdentityCache(t *testing.T, content string) string {
+	cacheFile := filepath.Join(t.TempDir(), "awsenv_identities.json")
+	origGetIdentityCacheFilePath := getIdentityCacheFilePath
+	getIdentityCacheFilePath = func() string { return cacheFile }
+	t.Cleanup(func() { getIdentityCacheFilePath = origGetIdentityCacheFilePath })
+
+	if content != "" {
+		if err := ioutil.WriteFile(cacheFile, []byte(content), 0600); err != nil {
+			t.Fatalf("setupIdentityCache: %v", err)
+		}
+	}
+	return cacheFile
+} //setupIdentityCache
+
+func TestWhoami(t *testing.T) {
+	stub := newIamStub(t, "12345678901234567890")
+	setupRotateTest(t, "one_profile_matching_default_credentials")
+	setupIdentityCache(t, `{
+  "ASIAEXPIRED": {"account": "111122223333", "arn": "arn:aws:sts::111122223333:assumed-role/Old/session", "expiration": "2020-01-01T00:00:00Z"},
+  "AKIAOTHER": {"account": "111122223333", "arn": "arn:aws:iam::111122223333:user/other"}
+}`)
+	st := loadTestStore(t)
+
+	identity, err := whoami(st, "profile_matching_default_credentials")
+	if err != nil {
+		t.Fatalf("TestWhoami: whoami failed: %v", err)
+	}
+	if identity.Account != "123456789012" || identity.Arn != "arn:aws:iam::123456789012:user/user" || identity.UserId != "AIDAEXAMPLE" {
+		t.Errorf("TestWhoami: unexpected identity %+v", identity)
+		t.Fail()
+	}
+	if len(stub.actions) != 1 || stub.actions[0] != "GetCallerIdentity 12345678901234567890" {
+		t.Errorf("TestWhoami: GetCallerIdentity was not signed with the key of the Profile: %v", stub.actions)
+		t.Fail()
+	}
+
+	identities := readIdentityCache()
+	if identities["12345678901234567890"].Arn != identity.Arn || identities["12345678901234567890"].CheckedAt.IsZero() {
+		t.Errorf("TestWhoami: identity was not cached: %+v", identities)
+		t.Fail()
+	}
+	if _, ok := identities["ASIAEXPIRED"]; ok {
+		t.Errorf("TestWhoami: identity of expired credentials was not dropped")
+		t.Fail()
+	}
+	if _, ok := identities["AKIAOTHER"]; !ok {
+		t.Errorf("TestWhoami: identity of another long-term key was dropped")
+		t.Fail()
+	}
+
+	if _, err := whoami(st, store.DefaultSection); err != nil {
+		t.Errorf("TestWhoami: whoami of the default section failed: %v", err)
+		t.Fail()
+	}
+
+	var buf bytes.Buffer
+	printIdentity(&buf, "default", identity)
+	if !strings.Contains(buf.String(), "Account: 123456789012\n") || !strings.Contains(buf.String(), "Arn:     arn:aws:iam::123456789012:user/user\n") {
+		t.Errorf("TestWhoami: unexpected output:\n%s", buf.String())
+		t.Fail()
+	}
+} //TestWhoami
+
+func TestWhoamiErrors(t *testing.T) {
+	stub := newIamStub(t)
+	setupIdentityCache(t, "")
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/config_only_profiles_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/config_only_profiles_config")
+	st := loadTestStore(t)
+
+	if _, err := whoami(st, "missing"); !errors.Is(err, store.ErrProfileNotFound) {
+		t.Errorf("TestWhoamiErrors: unknown Profile didn't fail with ErrProfileNotFound: %v", err)
+		t.Fail()
+	}
+	if _, err := whoami(st, "sso-dev"); err == nil {
+		t.Errorf("TestWhoamiErrors: sso Profile didn't fail")
+		t.Fail()
+	}
+
+	//the stub doesn't know the key of prod
+	_, err := whoami(st, "prod")
+	var apiErr *ApiError
+	if !errors.As(err, &apiErr) || apiErr.Code != "InvalidClientTokenId" {
+		t.Errorf("TestWhoamiErrors: rejected key didn't fail with the api error: %v", err)
+		t.Fail()
+	}
+	if len(stub.actions) != 1 || len(readIdentityCache()) != 0 {
+		t.Errorf("TestWhoamiErrors: failed calls must not be cached: %v", readIdentityCache())
+		t.Fail()
+	}
+} //TestWhoamiErrors
+
+func TestIdentityColumn(t *testing.T) {
+	setupIdentityCache(t, `{"12345678901234567890": {"account": "123456789012", "arn": "arn:aws:iam::123456789012:user/alice"}}`)
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "./testdata/config_only_profiles_credentials")
+	os.Setenv("AWS_CONFIG_FILE", "./testdata/config_only_profiles_config")
+	st := loadTestStore(t)
+	rows := []store.Profile{testProfile(t, st, "prod"), testProfile(t, st, "admin")}
+
+	var buf bytes.Buffer
+	if err := renderProfileTable(&buf, st, rows, tableOptions{columns: withIdentityColumn([]string{"name"})}); err != nil {
+		t.Fatalf("TestIdentityColumn: %v", err)
+	}
+	expected := "  PROFILE   IDENTITY\n" +
+		"  prod      arn:aws:iam::123456789012:user/alice\n" +
+		"  admin\n"
+	if buf.String() != expected {
+		t.Errorf("TestIdentityColumn: table is not\n%s\nactual:\n%s", expected, buf.String())
+		t.Fail()
+	}
+
+	if columns := withIdentityColumn(nil); len(columns) != len(profileColumns)+1 || columns[len(columns)-1] != "identity" {
+		t.Errorf("TestIdentityColumn: identity wasn't added to the default columns: %v", columns)
+		t.Fail()
+	}
+	if columns := withIdentityColumn([]string{"identity", "name"}); len(columns) != 2 {
+		t.Errorf("TestIdentityColumn: identity was added twice: %v", columns)
+		t.Fail()
+	}
+} //TestIdentityColumn
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+//types of access keys depending on the prefix of their id
+const (
+	KeyTypeLongTerm  = "long-term"
+	KeyTypeTemporary = "temporary"
+	KeyTypeOther     = "other"
+)
+
+//AccessKeyType returns whether an access key id belongs to a long-term key of an IAM user (AKIA) or to
+//temporary credentials of STS (ASIA). Other or malformed ids are of type other, empty ids of no type.
+func AccessKeyType(accessKeyId string) string {
+	switch {
+	case accessKeyId == "":
+		return ""
+	case strings.HasPrefix(accessKeyId, "AKIA"):
+		return KeyTypeLongTerm
+	case strings.HasPrefix(accessKeyId, "ASIA"):
+		return KeyTypeTemporary
+	}
+	return KeyTypeOther
+} //AccessKeyType
+
+//AccessKeyAccount decodes the id of the account owning an AKIA or ASIA access key without calling AWS.
+//The characters after the prefix are base32 encoded and bits 1 to 40 of the first 6 bytes hold the account.
+//False is returned for ids which don't have this format, e.g. keys of other prefixes or test keys.
+func AccessKeyAccount(accessKeyId string) (string, bool) {
+	if AccessKeyType(accessKeyId) != KeyTypeLongTerm && AccessKeyType(accessKeyId) != KeyTypeTemporary {
+		return "", false
+	}
+	if len(accessKeyId) != 20 {
+		return "", false
+	}
+	decoded, err := base32.StdEncoding.DecodeString(accessKeyId[4:])
+	if err != nil {
+		return "", false
+	}
+	prefix := make([]byte, 8)
+	copy(prefix[2:], decoded[:6])
+	account := (binary.BigEndian.Uint64(prefix) & 0x7fffffffff80) >> 7
+	return fmt.Sprintf("%012d", account), true
+} //AccessKeyAccount
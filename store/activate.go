@@ -0,0 +1,165 @@
+package store
+
+import (
+	"github.com/BernhardLenz/ini"
+	"time"
+)
+
+//format of the timestamp in the name of the backup sections, e.g. default-20210414093000
+const backupTimestampFormat = "20060102150405"
+
+//Activate makes a Profile the default by copying its keys into the default section of the credentials file.
+//Profiles which only exist in the config file are activated by copying their credential source into the
+//[default] section of the config file instead. The files are not saved.
+func (s *Store) Activate(profileName string) error {
+	if profileName == DefaultSection {
+		return &ProfileError{ErrDefaultNotActivatable, DefaultSection}
+	}
+	if _, ok := s.profiles[profileName]; !ok {
+		return &ProfileError{ErrProfileNotFound, profileName}
+	}
+
+	defaultSection, err := s.clearDefaultSection()
+	if err != nil {
+		return err
+	}
+
+	//profiles which only exist in the config file leave the default credentials section empty
+	if fromSection, err := s.credentialsFile.GetSection(profileName); err == nil {
+		for _, key := range fromSection.Keys() {
+			if _, err := defaultSection.NewKey(key.Name(), key.Value()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.setDefaultCredentialSource(profileName); err != nil {
+		return err
+	}
+
+	s.parse()
+	return nil
+} //Activate
+
+//SetDefaultCredentials writes temporary credentials, e.g. of an assumed role, into the default section.
+//The credential source in the config file is removed as it would take precedence over the keys.
+//The files are not saved.
+func (s *Store) SetDefaultCredentials(creds Credentials) error {
+	defaultSection, err := s.clearDefaultSection()
+	if err != nil {
+		return err
+	}
+
+	if err := setKeys(defaultSection, creds, false); err != nil {
+		return err
+	}
+
+	if err := s.setDefaultCredentialSource(""); err != nil {
+		return err
+	}
+
+	s.parse()
+	return nil
+} //SetDefaultCredentials
+
+//SetSessionProfile writes temporary credentials into a section of the credentials file. Long-term keys
+//which may have been stored in the section manually are backed up before they are overwritten. Like the
+//backups of the default section the backup is only listed by Backups if the section is named <profile>-mfa.
+//The credentials file is not saved.
+func (s *Store) SetSessionProfile(sectionName string, creds Credentials) error {
+	existing := s.credentialsFile.Section(sectionName)
+	backup := existing.HasKey("aws_access_key_id") && !existing.HasKey("aws_session_token")
+
+	section, err := s.clearSection(sectionName, backup)
+	if err != nil {
+		return err
+	}
+	if err := setKeys(section, creds, true); err != nil {
+		return err
+	}
+
+	s.parse()
+	return nil
+} //SetSessionProfile
+
+//ReplaceStaticCredentials replaces the keys of a Profile in the credentials file, e.g. after they have been
+//rotated, keeping all other keys of the section. All other sections holding the replaced keys are updated as
+//well, e.g. the default section if the Profile is active or profiles sharing the key, as the replaced key
+//may not be usable anymore. The credentials file is not saved.
+func (s *Store) ReplaceStaticCredentials(profileName string, creds Credentials) error {
+	section, err := s.credentialsFile.GetSection(profileName)
+	if err != nil || profileName == DefaultSection {
+		return &ProfileError{ErrProfileNotFound, profileName}
+	}
+
+	sections := []*ini.Section{section}
+	if oldKey := keyValue(section, "aws_access_key_id"); oldKey != "" {
+		for _, other := range s.credentialsFile.Sections() {
+			if other.Name() != profileName && keyValue(other, "aws_access_key_id") == oldKey {
+				sections = append(sections, other)
+			}
+		}
+	}
+	for _, section := range sections {
+		if err := setKeys(section, creds, false); err != nil {
+			return err
+		}
+	}
+
+	s.credentialsModified = true
+	s.parse()
+	return nil
+} //ReplaceStaticCredentials
+
+//setKeys writes credentials into a section of the credentials file
+func setKeys(section *ini.Section, creds Credentials, withExpiration bool) error {
+	keys := [][2]string{
+		{"aws_access_key_id", creds.AccessKeyId},
+		{"aws_secret_access_key", creds.SecretAccessKey},
+		{"aws_session_token", creds.SessionToken},
+	}
+	if withExpiration {
+		keys = append(keys, [2]string{"expiration", creds.Expiration.UTC().Format(time.RFC3339)})
+	}
+	for _, key := range keys {
+		if key[1] == "" {
+			continue
+		}
+		if _, err := section.NewKey(key[0], key[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+} //setKeys
+
+//clearDefaultSection removes all keys from the default section of the credentials file
+//after making a backup of them if needed
+func (s *Store) clearDefaultSection() (*ini.Section, error) {
+	//the default section is only active if there is no matching Profile present
+	//temporary credentials expire anyway and are not worth a backup
+	return s.clearSection(DefaultSection, s.defaultProfile.IsActive && s.defaultProfile.AwsSessionToken == "")
+} //clearDefaultSection
+
+//clearSection removes all keys from a section of the credentials file. If backup is true the keys
+//are copied into a new section named e.g. default-YYYYMMDDhhmmss first so they don't get lost.
+func (s *Store) clearSection(sectionName string, backup bool) (*ini.Section, error) {
+	section := s.credentialsFile.Section(sectionName)
+
+	if backup {
+		backupSection, err := s.credentialsFile.NewSection(s.backupSectionName(sectionName))
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range section.Keys() {
+			if _, err := backupSection.NewKey(key.Name(), key.Value()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, key := range section.Keys() {
+		section.DeleteKey(key.Name())
+	}
+	s.credentialsModified = true
+	return section, nil
+} //clearSection
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"github.com/BernhardLenz/ini"
+	"regexp"
+	"sort"
+	"time"
+)
+
+//backups are made of the default section and of the <profile>-mfa sections of mfa sessions
+var backupSectionNamePattern = regexp.MustCompile(`^(default|.+-mfa)-(\d{14})$`)
+
+//Backup is a copy of a standalone default section which Activate made before overwriting it, or of the
+//long-term keys of a <profile>-mfa section which SetSessionProfile made before writing the session into it.
+//The region and output of the default config are kept in a section of the same name in the config file
+//if SwitchDefaultConfig overwrote them.
+type Backup struct {
+	SectionName string
+	//Section is the section the backup has been made of and is restored into
+	Section        string
+	Timestamp      time.Time
+	AwsAccessKeyId string
+	Region         string
+	Output         string
+	//InCredentials and InConfig tell which of the files contain the backup section
+	InCredentials bool
+	InConfig      bool
+}
+
+//parseBackupSectionName returns the time a backup section has been created or false if it isn't a backup
+func parseBackupSectionName(sectionName string) (time.Time, bool) {
+	match := backupSectionNamePattern.FindStringSubmatch(sectionName)
+	if match == nil {
+		return time.Time{}, false
+	}
+	timestamp, err := time.ParseInLocation(backupTimestampFormat, match[2], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return timestamp, true
+} //parseBackupSectionName
+
+//addBackup adds a backup section of the credentials or config file. Sections of the same name in both files
+//are one backup.
+func (s *Store) addBackup(section *ini.Section, timestamp time.Time, file string) {
+	i := 0
+	for i < len(s.backups) && s.backups[i].SectionName != section.Name() {
+		i++
+	}
+	if i == len(s.backups) {
+		backupOf := backupSectionNamePattern.FindStringSubmatch(section.Name())[1]
+		s.backups = append(s.backups, Backup{SectionName: section.Name(), Section: backupOf, Timestamp: timestamp})
+	}
+
+	backup := &s.backups[i]
+	if file == FileConfig {
+		backup.InConfig = true
+		backup.Region = keyValue(section, "region")
+		backup.Output = keyValue(section, "output")
+	} else {
+		backup.InCredentials = true
+		backup.AwsAccessKeyId = keyValue(section, "aws_access_key_id")
+	}
+
+	sort.SliceStable(s.backups, func(i, j int) bool {
+		return s.backups[i].Timestamp.After(s.backups[j].Timestamp)
+	})
+} //addBackup
+
+//backupSectionName returns the name of the section a backup of the given section is copied into.
+//All backups until the next Save share the same timestamp, so backups of the credentials and config file
+//made by one activation are restored together.
+func (s *Store) backupSectionName(sectionName string) string {
+	if s.backupTime.IsZero() {
+		s.backupTime = time.Now()
+	}
+	return sectionName + "-" + s.backupTime.Format(backupTimestampFormat)
+} //backupSectionName
+
+//Backups returns the backups of the default and the mfa session sections from newest to oldest
+func (s *Store) Backups() []Backup {
+	return append([]Backup(nil), s.backups...)
+} //Backups
+
+//FindBackup looks up a backup by its timestamp, its section name or "latest". A timestamp shared by the
+//backups of several sections is ambiguous and returned as BackupError of kind ErrBackupAmbiguous.
+func (s *Store) FindBackup(ref string) (Backup, error) {
+	if ref == "latest" {
+		if len(s.backups) == 0 {
+			return Backup{}, &BackupError{Kind: ErrBackupNotFound, Ref: ref}
+		}
+		return s.backups[0], nil
+	}
+	matches := make([]Backup, 0)
+	for _, backup := range s.backups {
+		if backup.SectionName == ref {
+			return backup, nil
+		}
+		if backup.SectionName == backup.Section+"-"+ref {
+			matches = append(matches, backup)
+		}
+	}
+	if len(matches) > 1 {
+		sectionNames := make([]string, 0, len(matches))
+		for _, backup := range matches {
+			sectionNames = append(sectionNames, backup.SectionName)
+		}
+		return Backup{}, &BackupError{Kind: ErrBackupAmbiguous, Ref: ref, SectionNames: sectionNames}
+	}
+	if len(matches) == 0 {
+		return Backup{}, &BackupError{Kind: ErrBackupNotFound, Ref: ref}
+	}
+	return matches[0], nil
+} //FindBackup
+
+//RestoreBackup copies a backup into the sections it has been made of and removes the backup sections.
+//The files are not saved.
+func (s *Store) RestoreBackup(backup Backup) error {
+	if backup.InCredentials {
+		backupSection := s.credentialsFile.Section(backup.SectionName)
+
+		var section *ini.Section
+		var err error
+		if backup.Section == DefaultSection {
+			section, err = s.clearDefaultSection()
+			//the keys of the backup would be shadowed by the credential source of an activated role, sso or
+			//credential_process Profile
+			if err == nil {
+				err = s.setDefaultCredentialSource("")
+			}
+		} else {
+			//the session which replaced the backup expires anyway
+			section, err = s.clearSection(backup.Section, false)
+		}
+		if err != nil {
+			return err
+		}
+		for _, key := range backupSection.Keys() {
+			if _, err := section.NewKey(key.Name(), key.Value()); err != nil {
+				return err
+			}
+		}
+
+		s.credentialsFile.DeleteSection(backup.SectionName)
+	}
+
+	if backup.InConfig {
+		if err := s.setDefaultConfigValues(backup.Region, backup.Output); err != nil {
+			return err
+		}
+		s.configFile.DeleteSection(backup.SectionName)
+		s.configModified = true
+	}
+
+	s.parse()
+	return nil
+} //RestoreBackup
+
+//PruneBackups deletes backups from the credentials and config file. The newest keep backups are always kept,
+//the other ones are deleted if they are older than olderThan, or regardless of their age if olderThan is 0.
+//The files are not saved.
+func (s *Store) PruneBackups(keep int, olderThan time.Duration, now time.Time) []Backup {
+	pruned := make([]Backup, 0)
+	for i, backup := range s.backups {
+		if i < keep {
+			continue
+		}
+		if olderThan > 0 && now.Sub(backup.Timestamp) < olderThan {
+			continue
+		}
+		if backup.InCredentials {
+			s.credentialsFile.DeleteSection(backup.SectionName)
+			s.credentialsModified = true
+		}
+		if backup.InConfig {
+			s.configFile.DeleteSection(backup.SectionName)
+			s.configModified = true
+		}
+		pruned = append(pruned, backup)
+	}
+	if len(pruned) > 0 {
+		s.parse()
+	}
+	return pruned
+} //PruneBackups
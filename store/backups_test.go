@@ -0,0 +1,172 @@
+package store
+
+import (
+	"errors"
+	"github.com/BernhardLenz/ini"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupBackupsTest(t *testing.T) *Store {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/backups_credentials"
+	s.configPath = "../testdata/empty_file"
+	s.testLoad(t)
+	return s
+} //setupBackupsTest
+
+func TestBackupsHiddenFromProfiles(t *testing.T) {
+	s := setupBackupsTest(t)
+
+	//default-old is not a backup created by awsenv
+	if len(s.profiles) != 2 {
+		t.Errorf("TestBackupsHiddenFromProfiles: len(s.profiles) is not 2: %d \n %v", len(s.profiles), s.profiles)
+		t.Fail()
+	}
+
+	expected := []string{"default-20210301120000", "default-20210201120000", "default-20210101120000"}
+	if len(s.backups) != len(expected) {
+		t.Fatalf("TestBackupsHiddenFromProfiles: len(s.backups) is not %d: %v", len(expected), s.backups)
+	}
+	for i, backup := range s.backups {
+		if backup.SectionName != expected[i] {
+			t.Errorf("TestBackupsHiddenFromProfiles: backup %d is not '%s': %s", i, expected[i], backup.SectionName)
+			t.Fail()
+		}
+	}
+
+	if s.backups[0].AwsAccessKeyId != "12345678901234567892" {
+		t.Errorf("TestBackupsHiddenFromProfiles: aws_access_key_id of the latest backup is not '12345678901234567892': %s", s.backups[0].AwsAccessKeyId)
+		t.Fail()
+	}
+} //TestBackupsHiddenFromProfiles
+
+func TestFindBackup(t *testing.T) {
+	s := setupBackupsTest(t)
+
+	refs := map[string]string{
+		"latest":                 "default-20210301120000",
+		"20210201120000":         "default-20210201120000",
+		"default-20210101120000": "default-20210101120000",
+	}
+	for ref, sectionName := range refs {
+		if backup, err := s.FindBackup(ref); err != nil || backup.SectionName != sectionName {
+			t.Errorf("TestFindBackup: '%s' does not find '%s': %v %v", ref, sectionName, backup, err)
+			t.Fail()
+		}
+	}
+
+	if _, err := s.FindBackup("20200101000000"); !errors.Is(err, ErrBackupNotFound) {
+		t.Errorf("TestFindBackup: a non existing backup has been found: %v", err)
+		t.Fail()
+	}
+
+	//backups of mfa session sections are found by their timestamp as well unless another backup shares it
+	for _, sectionName := range []string{"dev-mfa-20210401120000", "prod-mfa-20210201120000"} {
+		if _, err := s.credentialsFile.NewSection(sectionName); err != nil {
+			t.Fatalf("TestFindBackup: %v", err)
+		}
+	}
+	s.parse()
+	if backup, err := s.FindBackup("20210401120000"); err != nil || backup.SectionName != "dev-mfa-20210401120000" || backup.Section != "dev-mfa" {
+		t.Errorf("TestFindBackup: '20210401120000' does not find 'dev-mfa-20210401120000': %v %v", backup, err)
+		t.Fail()
+	}
+	_, err := s.FindBackup("20210201120000")
+	if !errors.Is(err, ErrBackupAmbiguous) || !strings.Contains(err.Error(), "prod-mfa-20210201120000") || !strings.Contains(err.Error(), "default-20210201120000") {
+		t.Errorf("TestFindBackup: a timestamp of two backups is not ambiguous: %v", err)
+		t.Fail()
+	}
+	if backup, err := s.FindBackup("prod-mfa-20210201120000"); err != nil || backup.Section != "prod-mfa" {
+		t.Errorf("TestFindBackup: 'prod-mfa-20210201120000' is not found by its section name: %v %v", backup, err)
+		t.Fail()
+	}
+} //TestFindBackup
+
+func TestRestoreBackup(t *testing.T) {
+	s := setupBackupsTest(t)
+
+	backup, _ := s.FindBackup("latest")
+	_ = s.RestoreBackup(backup)
+
+	if key := s.credentialsFile.Section(ini.DefaultSection).Key("aws_access_key_id").Value(); key != "12345678901234567892" {
+		t.Errorf("TestRestoreBackup: default aws_access_key_id is not the one of the backup: %s", key)
+		t.Fail()
+	}
+
+	if _, err := s.credentialsFile.GetSection("default-20210301120000"); err == nil {
+		t.Errorf("TestRestoreBackup: restored backup section still exists")
+		t.Fail()
+	}
+} //TestRestoreBackup
+
+func TestRestoreBackupAfterConfigOnlyProfile(t *testing.T) {
+	for _, profileName := range []string{"sso-dev", "admin"} {
+		s := newTestStore()
+		s.credentialsPath = copyTestdata(t, "only_default_credentials", 0600)
+		s.configPath = copyTestdata(t, "config_only_profiles_config", 0600)
+		s.testLoad(t)
+
+		if err := s.Activate(profileName); err != nil {
+			t.Fatalf("TestRestoreBackupAfterConfigOnlyProfile: Activate %s failed: %v", profileName, err)
+		}
+		backup, err := s.FindBackup("latest")
+		if err != nil {
+			t.Fatalf("TestRestoreBackupAfterConfigOnlyProfile: no backup made by activating %s: %v", profileName, err)
+		}
+		if err := s.RestoreBackup(backup); err != nil {
+			t.Fatalf("TestRestoreBackupAfterConfigOnlyProfile: RestoreBackup failed: %v", err)
+		}
+		if err := s.Save(); err != nil {
+			t.Fatalf("TestRestoreBackupAfterConfigOnlyProfile: Save failed: %v", err)
+		}
+		s.testLoad(t)
+
+		if kind := configKind(s.defaultConfig); kind != "" {
+			t.Errorf("TestRestoreBackupAfterConfigOnlyProfile: default config still has the %s credential source of %s: %v", kind, profileName, s.defaultConfig)
+			t.Fail()
+		}
+		if s.defaultProfile.Kind != KindStatic || s.defaultProfile.AwsAccessKeyId != "12345678901234567890" || s.profiles[profileName].IsActive {
+			t.Errorf("TestRestoreBackupAfterConfigOnlyProfile: static keys of the backup are not active after %s: %v", profileName, s.defaultProfile)
+			t.Fail()
+		}
+	}
+} //TestRestoreBackupAfterConfigOnlyProfile
+
+func TestPruneBackups(t *testing.T) {
+	now, _ := time.ParseInLocation(backupTimestampFormat, "20210315120000", time.Local)
+
+	tests := []struct {
+		keep      int
+		olderThan time.Duration
+		pruned    []string
+	}{
+		{1, 0, []string{"default-20210201120000", "default-20210101120000"}},
+		{0, 30 * 24 * time.Hour, []string{"default-20210201120000", "default-20210101120000"}},
+		{2, 30 * 24 * time.Hour, []string{"default-20210101120000"}},
+		{0, 90 * 24 * time.Hour, []string{}},
+	}
+
+	for _, test := range tests {
+		s := setupBackupsTest(t)
+
+		pruned := s.PruneBackups(test.keep, test.olderThan, now)
+
+		if len(pruned) != len(test.pruned) {
+			t.Errorf("TestPruneBackups: --keep %d --older-than %v did not prune %v: %v", test.keep, test.olderThan, test.pruned, pruned)
+			continue
+		}
+		for i, backup := range pruned {
+			if backup.SectionName != test.pruned[i] {
+				t.Errorf("TestPruneBackups: --keep %d --older-than %v did not prune %v: %v", test.keep, test.olderThan, test.pruned, pruned)
+				t.Fail()
+			}
+			if _, err := s.credentialsFile.GetSection(backup.SectionName); err == nil {
+				t.Errorf("TestPruneBackups: pruned section %s still exists", backup.SectionName)
+				t.Fail()
+			}
+		}
+	}
+} //TestPruneBackups
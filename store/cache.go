@@ -0,0 +1,95 @@
+package store
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//cached credentials are only used if they are valid for at least this long
+const cacheExpiryWindow = 5 * time.Minute
+
+//CliCacheDir returns the directory the aws cli caches assumed role credentials in.
+//It is a method pointer which can be changed during test case execution.
+var CliCacheDir = func() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "cli", "cache")
+}
+
+type AssumedRoleUser struct {
+	AssumedRoleId string `json:"AssumedRoleId" xml:"AssumedRoleId"`
+	Arn           string `json:"Arn" xml:"Arn"`
+}
+
+//RoleCacheFile has the same format as the files the aws cli writes to ~/.aws/cli/cache
+type RoleCacheFile struct {
+	Credentials     Credentials     `json:"Credentials"`
+	AssumedRoleUser AssumedRoleUser `json:"AssumedRoleUser"`
+}
+
+//RoleCacheKey computes the same cache file name as the aws cli, i.e. the sha1 of the sorted json
+//encoded AssumeRole arguments without the RoleSessionName
+func RoleCacheKey(config Config) string {
+	args := map[string]string{"RoleArn": strconv.Quote(config.RoleArn)}
+	if config.ExternalId != "" {
+		args["ExternalId"] = strconv.Quote(config.ExternalId)
+	}
+	if config.MfaSerial != "" {
+		args["SerialNumber"] = strconv.Quote(config.MfaSerial)
+	}
+	if config.DurationSeconds != "" {
+		args["DurationSeconds"] = config.DurationSeconds
+	}
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, strconv.Quote(name)+": "+args[name])
+	}
+
+	sum := sha1.Sum([]byte("{" + strings.Join(pairs, ", ") + "}"))
+	return hex.EncodeToString(sum[:])
+} //RoleCacheKey
+
+func roleCacheFileName(config Config) string {
+	return filepath.Join(CliCacheDir(), RoleCacheKey(config)+".json")
+} //roleCacheFileName
+
+//ReadCachedRoleCredentials returns the cached credentials of a role if they haven't expired yet
+func ReadCachedRoleCredentials(config Config) (Credentials, bool) {
+	content, err := ioutil.ReadFile(roleCacheFileName(config))
+	if err != nil {
+		return Credentials{}, false
+	}
+	var cache RoleCacheFile
+	if err := json.Unmarshal(content, &cache); err != nil {
+		return Credentials{}, false
+	}
+	if cache.Credentials.AccessKeyId == "" || time.Until(cache.Credentials.Expiration) < cacheExpiryWindow {
+		return Credentials{}, false
+	}
+	return cache.Credentials, true
+} //ReadCachedRoleCredentials
+
+func WriteCachedRoleCredentials(config Config, cache RoleCacheFile) error {
+	content, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(CliCacheDir(), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(roleCacheFileName(config), content, 0600)
+} //WriteCachedRoleCredentials
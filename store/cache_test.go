@@ -0,0 +1,45 @@
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestRoleCacheKey(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/config_only_profiles_credentials"
+	s.configPath = "../testdata/role_chain_config"
+	s.testLoad(t)
+
+	//expected values are the file names the aws cli uses for the same profiles
+	expected := map[string]string{
+		"admin": "51e7b105b5040ce662656c8dc6ab4de70c4de7ad",
+		"mfa":   "5c6adf10b7ce7f377e485765d76915483a96a39a",
+	}
+	for name, key := range expected {
+		if actual := RoleCacheKey(s.configs[name]); actual != key {
+			t.Errorf("TestRoleCacheKey: cache key of %s is not '%s': %s", name, key, actual)
+			t.Fail()
+		}
+	}
+} //TestRoleCacheKey
+
+func TestReadExpiredCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	origCliCacheDir := CliCacheDir
+	t.Cleanup(func() { CliCacheDir = origCliCacheDir })
+	CliCacheDir = func() string { return cacheDir }
+
+	config := Config{RoleArn: "arn:aws:iam::123456789012:role/Admin"}
+	content := fmt.Sprintf(`{"Credentials":{"AccessKeyId":"ASIAEXPIRED","SecretAccessKey":"s","SessionToken":"t","Expiration":"%s"}}`,
+		time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+	_ = ioutil.WriteFile(roleCacheFileName(config), []byte(content), 0600)
+
+	if _, ok := ReadCachedRoleCredentials(config); ok {
+		t.Errorf("TestReadExpiredCache: expired credentials have been returned from the cache")
+		t.Fail()
+	}
+} //TestReadExpiredCache
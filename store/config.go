@@ -0,0 +1,221 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/BernhardLenz/ini"
+	"strings"
+)
+
+//keys of a config section which define how the credentials of a Profile are obtained
+var credentialSourceKeys = []string{
+	"role_arn",
+	"source_profile",
+	"credential_source",
+	"external_id",
+	"mfa_serial",
+	"role_session_name",
+	"duration_seconds",
+	"web_identity_token_file",
+	"sso_session",
+	"sso_start_url",
+	"sso_region",
+	"sso_account_id",
+	"sso_role_name",
+	"credential_process",
+}
+
+//setDefaultCredentialSource replaces the credential source keys of the [default] section in the config file
+//with the ones of the given Profile. This is how role, sso and credential_process profiles are activated
+//as there are no static keys which could be copied into the credentials file.
+//The config file is only marked as modified if the [default] section actually changes.
+func (s *Store) setDefaultCredentialSource(fromProfileName string) error {
+	defaultSection := s.configFile.Section(DefaultSection)
+	changed := false
+
+	for _, keyName := range credentialSourceKeys {
+		if defaultSection.HasKey(keyName) {
+			defaultSection.DeleteKey(keyName)
+			changed = true
+		}
+	}
+
+	if config, ok := s.configs[fromProfileName]; ok && fromProfileName != DefaultSection {
+		fromSection := s.configFile.Section(config.SectionName)
+		for _, keyName := range credentialSourceKeys {
+			if fromSection.HasKey(keyName) {
+				if _, err := defaultSection.NewKey(keyName, fromSection.Key(keyName).Value()); err != nil {
+					return err
+				}
+				changed = true
+			}
+		}
+	}
+
+	if changed {
+		s.configModified = true
+	}
+	return nil
+} //setDefaultCredentialSource
+
+//SetCredentialProcess replaces the credential source of a Profile in the config file with a credential_process
+//command. The keys of the default section in the credentials file are removed, as they would take precedence,
+//and backed up if they don't belong to any Profile. Other profiles must not have keys in the credentials file.
+//The files are not saved.
+func (s *Store) SetCredentialProcess(profileName string, command string) error {
+	var section *ini.Section
+	if profileName == DefaultSection {
+		if _, err := s.clearDefaultSection(); err != nil {
+			return err
+		}
+		section = s.configFile.Section(DefaultSection)
+	} else {
+		if s.HasCredentialsSection(profileName) {
+			return fmt.Errorf("Profile '%s' has keys in the credentials file which take precedence over credential_process", profileName)
+		}
+		sectionName := configSectionProfile + " " + profileName
+		if config, ok := s.configs[profileName]; ok {
+			sectionName = config.SectionName
+		}
+		section = s.configFile.Section(sectionName)
+	}
+
+	for _, keyName := range credentialSourceKeys {
+		section.DeleteKey(keyName)
+	}
+	if _, err := section.NewKey("credential_process", command); err != nil {
+		return err
+	}
+
+	s.configModified = true
+	s.parse()
+	return nil
+} //SetCredentialProcess
+
+//keys of the default config section which SwitchDefaultConfig copies from the activated Profile
+var defaultConfigKeys = []string{"region", "output"}
+
+//SwitchDefaultConfig copies region and output of the given Profile into the [default] section of the config file,
+//so the aws cli uses them after the Profile has been activated. Keys the Profile doesn't set are left unchanged.
+//If the previous values don't belong to any Profile they are backed up into a section of the config file
+//named like the backup of the credentials file. The config file is not saved.
+func (s *Store) SwitchDefaultConfig(profileName string) error {
+	config, ok := s.configs[profileName]
+	if !ok || profileName == DefaultSection {
+		return nil
+	}
+	fromSection := s.configFile.Section(config.SectionName)
+	defaultSection := s.configFile.Section(DefaultSection)
+
+	changed := false
+	for _, keyName := range defaultConfigKeys {
+		value := keyValue(fromSection, keyName)
+		if value != "" && value != keyValue(defaultSection, keyName) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if s.isStandaloneDefaultConfig() {
+		backupSection, err := s.configFile.NewSection(s.backupSectionName(DefaultSection))
+		if err != nil {
+			return err
+		}
+		for _, keyName := range defaultConfigKeys {
+			if value := keyValue(defaultSection, keyName); value != "" {
+				if _, err := backupSection.NewKey(keyName, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, keyName := range defaultConfigKeys {
+		if value := keyValue(fromSection, keyName); value != "" {
+			if _, err := defaultSection.NewKey(keyName, value); err != nil {
+				return err
+			}
+		}
+	}
+	s.configModified = true
+	s.parse()
+	return nil
+} //SwitchDefaultConfig
+
+//isStandaloneDefaultConfig returns true if the default config sets a region or output which isn't the one
+//of any Profile and would be lost if it was overwritten
+func (s *Store) isStandaloneDefaultConfig() bool {
+	if s.defaultConfig.Region == "" && s.defaultConfig.Output == "" {
+		return false
+	}
+	for name, config := range s.configs {
+		if name == DefaultSection {
+			continue
+		}
+		if config.Region == "" && config.Output == "" {
+			continue
+		}
+		//keys the Profile doesn't set are not switched
+		if (config.Region == "" || config.Region == s.defaultConfig.Region) &&
+			(config.Output == "" || config.Output == s.defaultConfig.Output) {
+			return false
+		}
+	}
+	return true
+} //isStandaloneDefaultConfig
+
+//setDefaultConfigValues sets region and output of the [default] section in the config file,
+//empty values remove the keys
+func (s *Store) setDefaultConfigValues(region string, output string) error {
+	defaultSection := s.configFile.Section(DefaultSection)
+	values := []string{region, output}
+	for i, keyName := range defaultConfigKeys {
+		if value := values[i]; value == "" {
+			defaultSection.DeleteKey(keyName)
+		} else if _, err := defaultSection.NewKey(keyName, value); err != nil {
+			return err
+		}
+	}
+	return nil
+} //setDefaultConfigValues
+
+//saveConfigFile writes the config file. The ini library writes the nested sub-sections of
+//[services NAME] sections as """ quoted multiline values which the aws cli doesn't understand,
+//so they are converted back to indented lines.
+//The written content is returned.
+func (s *Store) saveConfigFile(fileName string) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := s.configFile.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	content := unquoteMultilineValues(buf.Bytes())
+	return content, WriteFileAtomic(fileName, content)
+} //saveConfigFile
+
+func unquoteMultilineValues(content []byte) []byte {
+	var out bytes.Buffer
+	inValue := false
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+		newline := line[len(trimmed):]
+		if !inValue && strings.HasSuffix(trimmed, ` = """`) {
+			out.WriteString(strings.TrimSuffix(trimmed, ` """`) + newline)
+			inValue = true
+			continue
+		}
+		if inValue {
+			if strings.HasSuffix(trimmed, `"""`) {
+				trimmed = strings.TrimSuffix(trimmed, `"""`)
+				inValue = false
+			}
+			if trimmed != "" {
+				out.WriteString("  " + strings.TrimSpace(trimmed) + newline)
+			}
+			continue
+		}
+		out.WriteString(line)
+	}
+	return out.Bytes()
+} //unquoteMultilineValues
@@ -0,0 +1,239 @@
+package store
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestConfigOnlyProfiles(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/config_only_profiles_credentials"
+	s.configPath = "../testdata/config_only_profiles_config"
+	s.testLoad(t)
+
+	expectedKinds := map[string]string{
+		"prod":    KindStatic,
+		"admin":   KindRole,
+		"sso-dev": KindSso,
+		"vault":   KindProcess,
+	}
+
+	if len(s.profiles) != len(expectedKinds) {
+		t.Errorf("TestConfigOnlyProfiles: len(s.profiles) is not %d: %d \n %v", len(expectedKinds), len(s.profiles), s.profiles)
+		t.Fail()
+	}
+
+	for name, kind := range expectedKinds {
+		profile, ok := s.profiles[name]
+		if !ok {
+			t.Errorf("TestConfigOnlyProfiles: Profile '%s' is missing", name)
+			continue
+		}
+		if profile.Kind != kind {
+			t.Errorf("TestConfigOnlyProfiles: %s Profile.Kind is not '%s': %s ", name, kind, profile.Kind)
+			t.Fail()
+		}
+		if profile.IsActive {
+			t.Errorf("TestConfigOnlyProfiles: %s Profile.IsActive is true: %t ", name, profile.IsActive)
+			t.Fail()
+		}
+	}
+
+	if profile := s.profiles["sso-dev"]; profile.Region != "eu-west-1" {
+		t.Errorf("TestConfigOnlyProfiles: sso-dev Profile.Region is not 'eu-west-1': %s ", profile.Region)
+		t.Fail()
+	}
+} //TestConfigOnlyProfiles
+
+func TestSetDefaultCredentialSource(t *testing.T) {
+	s := newTestStore()
+
+	configFileName := copyTestdata(t, "config_only_profiles_config", 0600)
+
+	s.credentialsPath = copyTestdata(t, "config_only_profiles_credentials", 0600)
+	s.configPath = configFileName
+	s.testLoad(t)
+
+	activate := func(profileName string) {
+		if err := s.Activate(profileName); err != nil {
+			t.Fatalf("TestSetDefaultCredentialSource: Activate %s failed: %v", profileName, err)
+		}
+		if err := s.Save(); err != nil {
+			t.Fatalf("TestSetDefaultCredentialSource: Save failed: %v", err)
+		}
+		//read the saved files again
+		s.testLoad(t)
+	}
+
+	activate("admin")
+
+	if !s.profiles["admin"].IsActive {
+		t.Errorf("TestSetDefaultCredentialSource: admin Profile.IsActive is not true after activation")
+		t.Fail()
+	}
+
+	if s.defaultConfig.RoleArn != "arn:aws:iam::123456789012:role/Admin" {
+		t.Errorf("TestSetDefaultCredentialSource: s.defaultConfig.RoleArn is not 'arn:aws:iam::123456789012:role/Admin': %s ", s.defaultConfig.RoleArn)
+		t.Fail()
+	}
+
+	if s.defaultConfig.SourceProfile != "prod" {
+		t.Errorf("TestSetDefaultCredentialSource: s.defaultConfig.SourceProfile is not 'prod': %s ", s.defaultConfig.SourceProfile)
+		t.Fail()
+	}
+
+	if s.defaultConfig.Region != "us-east-1" {
+		t.Errorf("TestSetDefaultCredentialSource: s.defaultConfig.Region is not 'us-east-1': %s ", s.defaultConfig.Region)
+		t.Fail()
+	}
+
+	activate("sso-dev")
+
+	if s.profiles["admin"].IsActive || !s.profiles["sso-dev"].IsActive {
+		t.Errorf("TestSetDefaultCredentialSource: sso-dev Profile is not the only active Profile: %v", s.profiles)
+		t.Fail()
+	}
+
+	if s.defaultConfig.RoleArn != "" {
+		t.Errorf("TestSetDefaultCredentialSource: s.defaultConfig.RoleArn is not '': %s ", s.defaultConfig.RoleArn)
+		t.Fail()
+	}
+
+	//a static Profile removes the credential source from the default config section
+	activate("prod")
+
+	if configKind(s.defaultConfig) != "" {
+		t.Errorf("TestSetDefaultCredentialSource: s.defaultConfig still has a credential source: %v", s.defaultConfig)
+		t.Fail()
+	}
+
+	if endpoint := s.services["local"]["s3"]["endpoint_url"]; endpoint != "http://localhost:9000" {
+		t.Errorf("TestSetDefaultCredentialSource: s.services local s3 endpoint_url is not 'http://localhost:9000' after saving: %s ", endpoint)
+		t.Fail()
+	}
+
+	content, _ := ioutil.ReadFile(configFileName)
+	if strings.Contains(string(content), `"""`) {
+		t.Errorf("TestSetDefaultCredentialSource: saved config file contains multiline quotes:\n%s", content)
+		t.Fail()
+	}
+} //TestSetDefaultCredentialSource
+
+func TestSwitchDefaultConfig(t *testing.T) {
+	s := newTestStore()
+
+	configFileName := copyTestdata(t, "config_only_profiles_config", 0600)
+
+	s.credentialsPath = copyTestdata(t, "config_only_profiles_credentials", 0600)
+	s.configPath = configFileName
+	s.testLoad(t)
+
+	if err := s.Activate("prod"); err != nil {
+		t.Fatalf("TestSwitchDefaultConfig: Activate failed: %v", err)
+	}
+	if err := s.SwitchDefaultConfig("prod"); err != nil {
+		t.Fatalf("TestSwitchDefaultConfig: SwitchDefaultConfig failed: %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("TestSwitchDefaultConfig: Save failed: %v", err)
+	}
+	s.testLoad(t)
+
+	//output is not set by prod and stays unchanged
+	if s.defaultConfig.Region != "eu-central-1" || s.defaultConfig.Output != "json" {
+		t.Errorf("TestSwitchDefaultConfig: unexpected region '%s' and output '%s' of the default config", s.defaultConfig.Region, s.defaultConfig.Output)
+		t.Fail()
+	}
+
+	//us-east-1 of the default config doesn't belong to any Profile
+	backups := s.Backups()
+	if len(backups) != 1 || !backups[0].InConfig || backups[0].InCredentials || backups[0].Region != "us-east-1" || backups[0].Output != "json" {
+		t.Fatalf("TestSwitchDefaultConfig: unexpected backups: %v", backups)
+	}
+	if _, ok := s.profiles[backups[0].SectionName]; ok {
+		t.Errorf("TestSwitchDefaultConfig: backup section is listed as Profile")
+		t.Fail()
+	}
+
+	//switching to the region of a Profile doesn't make another backup
+	if err := s.SwitchDefaultConfig("sso-dev"); err != nil {
+		t.Fatalf("TestSwitchDefaultConfig: SwitchDefaultConfig failed: %v", err)
+	}
+	if len(s.Backups()) != 1 || s.defaultConfig.Region != "eu-west-1" {
+		t.Errorf("TestSwitchDefaultConfig: unexpected region '%s' or backups %v", s.defaultConfig.Region, s.Backups())
+		t.Fail()
+	}
+
+	if err := s.RestoreBackup(backups[0]); err != nil {
+		t.Fatalf("TestSwitchDefaultConfig: RestoreBackup failed: %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("TestSwitchDefaultConfig: Save failed: %v", err)
+	}
+	s.testLoad(t)
+
+	if s.defaultConfig.Region != "us-east-1" || len(s.Backups()) != 0 {
+		t.Errorf("TestSwitchDefaultConfig: backup not restored, region '%s' and backups %v", s.defaultConfig.Region, s.Backups())
+		t.Fail()
+	}
+
+	//the default credentials are not touched by restoring a backup of the config file
+	if !s.profiles["prod"].IsActive {
+		t.Errorf("TestSwitchDefaultConfig: prod Profile is not active after restoring the backup")
+		t.Fail()
+	}
+} //TestSwitchDefaultConfig
+
+func TestSwitchDefaultConfigUnchanged(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/config_only_profiles_credentials"
+	s.configPath = "../testdata/config_only_profiles_config"
+	s.testLoad(t)
+
+	//admin has no region or output
+	if err := s.SwitchDefaultConfig("admin"); err != nil {
+		t.Fatalf("TestSwitchDefaultConfigUnchanged: SwitchDefaultConfig failed: %v", err)
+	}
+	if s.configModified || len(s.Backups()) != 0 {
+		t.Errorf("TestSwitchDefaultConfigUnchanged: config file has been modified")
+		t.Fail()
+	}
+} //TestSwitchDefaultConfigUnchanged
+
+func TestSetCredentialProcess(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/mfa_credentials"
+	s.configPath = "../testdata/mfa_config"
+	s.testLoad(t)
+
+	if err := s.SetCredentialProcess(DefaultSection, "awsenv credential-process prod"); err != nil {
+		t.Fatalf("TestSetCredentialProcess: SetCredentialProcess failed: %v", err)
+	}
+
+	//the keys of the default section would take precedence and are backed up as they don't match any Profile
+	if s.defaultProfile.AwsAccessKeyId != "" || len(s.Backups()) != 1 {
+		t.Errorf("TestSetCredentialProcess: default keys not moved into a backup: %v %v", s.defaultProfile, s.Backups())
+		t.Fail()
+	}
+	if configKind(s.defaultConfig) != KindProcess || s.defaultConfig.MfaSerial != "" {
+		t.Errorf("TestSetCredentialProcess: credential source of the default config not replaced: %v", s.defaultConfig)
+		t.Fail()
+	}
+
+	if err := s.SetCredentialProcess("dev", "awsenv credential-process prod"); err == nil {
+		t.Errorf("TestSetCredentialProcess: credential_process set for a Profile with keys in the credentials file")
+		t.Fail()
+	}
+
+	if err := s.SetCredentialProcess("wired", "awsenv credential-process prod"); err != nil {
+		t.Fatalf("TestSetCredentialProcess: SetCredentialProcess failed: %v", err)
+	}
+	if config, _ := s.Config("wired"); config.SectionName != "profile wired" || s.profiles["wired"].Kind != KindProcess {
+		t.Errorf("TestSetCredentialProcess: wired Profile not created: %v", config)
+		t.Fail()
+	}
+} //TestSetCredentialProcess
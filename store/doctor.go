@@ -0,0 +1,418 @@
+package store
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+//severities of the findings of Lint, errors make the aws cli fail or use other credentials than expected
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+//checks run by Lint
+const (
+	CheckDuplicateSection = "duplicate-section"
+	CheckCaseCollision    = "case-collision"
+	CheckMissingSecret    = "missing-secret"
+	CheckMalformedKeyId   = "malformed-key-id"
+	CheckNoCredentials    = "no-credentials"
+	CheckSourceProfile    = "source-profile"
+	CheckSecretInConfig   = "secret-in-config"
+	CheckFileMode         = "file-mode"
+)
+
+//keys of the credentials file which Lint expects to find in the credentials and not in the config file
+var credentialKeys = []string{"aws_access_key_id", "aws_secret_access_key", "aws_session_token"}
+
+//Finding is a problem Lint found in the credentials or config file
+type Finding struct {
+	Severity string
+	Check    string
+	//Path of the file and line of the section or key, 0 if the finding applies to the whole file
+	Path    string
+	Line    int
+	Section string
+	Message string
+	//fix corrects the finding if this is safe, nil otherwise
+	fix func() error
+}
+
+//Fixable returns true if Fix can correct the finding without losing or changing any credentials
+func (f Finding) Fixable() bool {
+	return f.fix != nil
+} //Fixable
+
+//sectionHeader is a section of a file as it is written, Lint needs to see the duplicates the ini library merges
+type sectionHeader struct {
+	name string
+	line int
+}
+
+//scanSectionHeaders returns the section headers of the content of a file in their order
+func scanSectionHeaders(content []byte) []sectionHeader {
+	headers := make([]sectionHeader, 0)
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			headers = append(headers, sectionHeader{strings.TrimSpace(trimmed[1 : len(trimmed)-1]), i + 1})
+		}
+	}
+	return headers
+} //scanSectionHeaders
+
+//conflictingSections returns the sections of the content of a file which set a key to different values,
+//e.g. in two definitions of the section. Merging them would lose one of the values.
+func conflictingSections(content []byte) map[string]bool {
+	conflicts := make(map[string]bool)
+	values := make(map[string]map[string]string)
+	section := ""
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if values[section] == nil {
+				values[section] = make(map[string]string)
+			}
+			continue
+		}
+		equals := strings.Index(trimmed, "=")
+		if section == "" || equals == -1 || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		key, value := strings.TrimSpace(trimmed[:equals]), strings.TrimSpace(trimmed[equals+1:])
+		if previous, ok := values[section][key]; ok && previous != value {
+			conflicts[section] = true
+		}
+		values[section][key] = value
+	}
+	return conflicts
+} //conflictingSections
+
+//Lint checks the credentials and config file for problems the aws cli doesn't report or which make it
+//use other credentials than expected. The files are read again as the ini library hides duplicate sections.
+//The findings are sorted by file and line.
+func (s *Store) Lint() ([]Finding, error) {
+	findings := make([]Finding, 0)
+	//line of the first header of each section of the config file
+	configHeaders := make(map[string]int)
+
+	files := []struct {
+		path     string
+		modified *bool
+	}{
+		{s.credentialsPath, &s.credentialsModified},
+		{s.configPath, &s.configModified},
+	}
+	for _, file := range files {
+		info, err := os.Stat(file.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		content, err := ioutil.ReadFile(file.path)
+		if err != nil {
+			return nil, &FileError{ErrFileUnreadable, file.path, err}
+		}
+		findings = append(findings, lintSectionHeaders(file.path, content, file.modified)...)
+		if file.path == s.configPath {
+			for _, header := range scanSectionHeaders(content) {
+				if _, ok := configHeaders[header.name]; !ok {
+					configHeaders[header.name] = header.line
+				}
+			}
+		}
+
+		if perm := info.Mode().Perm(); perm&0077 != 0 && runtime.GOOS != "windows" {
+			path := file.path
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Check:    CheckFileMode,
+				Path:     path,
+				Message:  fmt.Sprintf("file mode %04o allows other users to read the file, it should be 0600", perm),
+				fix:      func() error { return os.Chmod(path, 0600) },
+			})
+		}
+	}
+
+	findings = append(findings, s.lintKeys()...)
+	findings = append(findings, s.lintConfigs(configHeaders)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path == s.credentialsPath
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+} //Lint
+
+//lintSectionHeaders reports duplicate sections, which the ini library merges and the aws cli refuses to read,
+//and sections whose names only differ in case. Duplicates are fixed by saving the merged sections unless
+//they set a key to different values, as the ini library only keeps the last of them.
+func lintSectionHeaders(path string, content []byte, modified *bool) []Finding {
+	findings := make([]Finding, 0)
+	headers := scanSectionHeaders(content)
+	conflicts := conflictingSections(content)
+	seen := make(map[string]int)
+	seenFolded := make(map[string]string)
+	for _, header := range headers {
+		seen[header.name]++
+		if seen[header.name] == 2 {
+			finding := Finding{
+				Severity: SeverityError,
+				Check:    CheckDuplicateSection,
+				Path:     path,
+				Line:     header.line,
+				Section:  header.name,
+				Message:  "section is defined more than once, the aws cli fails to read the file and awsenv merges the sections",
+				fix: func() error {
+					*modified = true
+					return nil
+				},
+			}
+			if conflicts[header.name] {
+				finding.Message = "section is defined more than once with different values, the aws cli fails to read the file and awsenv only uses the last values"
+				finding.fix = nil
+			}
+			findings = append(findings, finding)
+		}
+
+		//python's configparser applies the keys of [DEFAULT] to all other sections
+		if header.name == strings.ToUpper(DefaultSection) {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Check:    CheckCaseCollision,
+				Path:     path,
+				Line:     header.line,
+				Section:  header.name,
+				Message:  fmt.Sprintf("the aws cli uses the keys of [%s] in every profile, it is not the [%s] section", header.name, DefaultSection),
+			})
+			continue
+		}
+		folded := strings.ToLower(header.name)
+		if other, ok := seenFolded[folded]; ok && other != header.name {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Check:    CheckCaseCollision,
+				Path:     path,
+				Line:     header.line,
+				Section:  header.name,
+				Message:  fmt.Sprintf("section name only differs in case from [%s]", other),
+			})
+		} else if !ok {
+			seenFolded[folded] = header.name
+		}
+	}
+	return findings
+} //lintSectionHeaders
+
+//lintKeys checks the access keys of all sections of the credentials file and the profile sections of the config file
+func (s *Store) lintKeys() []Finding {
+	findings := make([]Finding, 0)
+	for _, section := range s.credentialsFile.Sections() {
+		if _, ok := parseBackupSectionName(section.Name()); ok || len(section.Keys()) == 0 {
+			continue
+		}
+		findings = append(findings, s.lintKeyPair(FileCredentials, section.Name(), keyValue(section, "aws_access_key_id"),
+			keyValue(section, "aws_secret_access_key"), keyValue(section, "aws_session_token"))...)
+	}
+	for _, section := range s.configFile.Sections() {
+		if sectionType, _ := splitConfigSectionName(section.Name()); sectionType == configSectionSsoSession || sectionType == configSectionServices {
+			continue
+		}
+		findings = append(findings, s.lintKeyPair(FileConfig, section.Name(), keyValue(section, "aws_access_key_id"),
+			keyValue(section, "aws_secret_access_key"), keyValue(section, "aws_session_token"))...)
+		findings = append(findings, s.lintSecretInConfig(section.Name())...)
+	}
+	return findings
+} //lintKeys
+
+//lintKeyPair reports access keys without secret or vice versa and access key ids which can't be valid
+func (s *Store) lintKeyPair(file string, sectionName string, accessKeyId string, secretAccessKey string, sessionToken string) []Finding {
+	findings := make([]Finding, 0)
+	if accessKeyId == "" && secretAccessKey == "" {
+		return findings
+	}
+	finding := func(check string, key string, message string) Finding {
+		return Finding{
+			Severity: SeverityError,
+			Check:    check,
+			Path:     s.filePath(file),
+			Line:     s.keyLine(file, sectionName, key),
+			Section:  sectionName,
+			Message:  message,
+		}
+	}
+
+	switch {
+	case secretAccessKey == "":
+		findings = append(findings, finding(CheckMissingSecret, "aws_access_key_id", "aws_access_key_id is set without aws_secret_access_key"))
+	case accessKeyId == "":
+		findings = append(findings, finding(CheckMissingSecret, "aws_secret_access_key", "aws_secret_access_key is set without aws_access_key_id"))
+	case AccessKeyType(accessKeyId) == KeyTypeTemporary && sessionToken == "":
+		findings = append(findings, finding(CheckMissingSecret, "aws_access_key_id", "temporary access key is set without aws_session_token"))
+	}
+	if _, ok := AccessKeyAccount(accessKeyId); accessKeyId != "" && !ok {
+		findings = append(findings, finding(CheckMalformedKeyId, "aws_access_key_id", "aws_access_key_id is not a 20 character AKIA or ASIA access key id"))
+	}
+	return findings
+} //lintKeyPair
+
+//lintSecretInConfig reports keys of the config file which belong into the credentials file. They are moved
+//if the credentials file has no access key for the Profile yet, or removed if it has the same one.
+func (s *Store) lintSecretInConfig(sectionName string) []Finding {
+	section := s.configFile.Section(sectionName)
+	if !section.HasKey("aws_secret_access_key") && !section.HasKey("aws_session_token") {
+		return nil
+	}
+	_, profileName := splitConfigSectionName(sectionName)
+	finding := Finding{
+		Severity: SeverityWarning,
+		Check:    CheckSecretInConfig,
+		Path:     s.configPath,
+		Line:     s.keyLine(FileConfig, sectionName, "aws_secret_access_key"),
+		Section:  sectionName,
+		Message:  fmt.Sprintf("secrets are stored in the config file instead of section [%s] of the credentials file", profileName),
+	}
+	if finding.Line == 0 {
+		finding.Line = s.keyLine(FileConfig, sectionName, "aws_session_token")
+	}
+
+	existing := keyValue(s.credentialsFile.Section(profileName), "aws_access_key_id")
+	if existing != "" && existing != keyValue(section, "aws_access_key_id") {
+		finding.Message += ", which has a different access key"
+		return []Finding{finding}
+	}
+	finding.fix = func() error {
+		credentialsSection := s.credentialsFile.Section(profileName)
+		for _, key := range credentialKeys {
+			if value := keyValue(section, key); value != "" {
+				if _, err := credentialsSection.NewKey(key, value); err != nil {
+					return err
+				}
+			}
+			section.DeleteKey(key)
+		}
+		s.credentialsModified = true
+		s.configModified = true
+		s.parse()
+		return nil
+	}
+	return []Finding{finding}
+} //lintSecretInConfig
+
+//lintConfigs reports profiles of the config file without any credentials and broken source_profile chains
+func (s *Store) lintConfigs(headers map[string]int) []Finding {
+	findings := make([]Finding, 0)
+	names := make([]string, 0, len(s.configs))
+	for name := range s.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reportedCycles := make(map[string]bool)
+	for _, name := range names {
+		config := s.configs[name]
+		if name == DefaultSection {
+			continue
+		}
+		if configKind(config) == "" && s.CredentialsValue(name, "aws_access_key_id") == "" && keyValue(s.configFile.Section(config.SectionName), "aws_access_key_id") == "" {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Check:    CheckNoCredentials,
+				Path:     s.configPath,
+				Line:     headers[config.SectionName],
+				Section:  config.SectionName,
+				Message:  fmt.Sprintf("Profile '%s' has neither keys in the credentials file nor role_arn, sso or credential_process", name),
+			})
+		}
+
+		//follow the source_profile chain, a role Profile may use its own static keys as source
+		chain := []string{name}
+		for current := name; ; {
+			next := s.configs[current].SourceProfile
+			if next == "" || next == current {
+				break
+			}
+			_, isConfig := s.configs[next]
+			if !isConfig && !s.HasCredentialsSection(next) {
+				if current == name {
+					findings = append(findings, Finding{
+						Severity: SeverityError,
+						Check:    CheckSourceProfile,
+						Path:     s.configPath,
+						Line:     s.keyLine(FileConfig, config.SectionName, "source_profile"),
+						Section:  config.SectionName,
+						Message:  fmt.Sprintf("source_profile '%s' does not exist", next),
+					})
+				}
+				break
+			}
+			if index := indexOf(chain, next); index >= 0 {
+				cycle := append([]string{}, chain[index:]...)
+				sort.Strings(cycle)
+				if key := strings.Join(cycle, ","); !reportedCycles[key] {
+					reportedCycles[key] = true
+					findings = append(findings, Finding{
+						Severity: SeverityError,
+						Check:    CheckSourceProfile,
+						Path:     s.configPath,
+						Line:     s.keyLine(FileConfig, s.configs[next].SectionName, "source_profile"),
+						Section:  s.configs[next].SectionName,
+						Message:  fmt.Sprintf("source_profile cycle: %s -> %s", strings.Join(chain[index:], " -> "), next),
+					})
+				}
+				break
+			}
+			chain = append(chain, next)
+			current = next
+		}
+	}
+	return findings
+} //lintConfigs
+
+//Fix corrects the fixable findings: duplicate sections are merged, secrets are moved from the config into the
+//credentials file and file modes are restricted to the user. File modes are changed right away, everything
+//else only in memory until Save. The fixed findings are returned.
+func (s *Store) Fix(findings []Finding) ([]Finding, error) {
+	fixed := make([]Finding, 0)
+	for _, finding := range findings {
+		if !finding.Fixable() {
+			continue
+		}
+		if err := finding.fix(); err != nil {
+			return fixed, err
+		}
+		fixed = append(fixed, finding)
+	}
+	return fixed, nil
+} //Fix
+
+func (s *Store) filePath(file string) string {
+	if file == FileConfig {
+		return s.configPath
+	}
+	return s.credentialsPath
+} //filePath
+
+//keyLine returns the line number of a key or 0 if it is unknown
+func (s *Store) keyLine(file string, sectionName string, key string) int {
+	if file == FileConfig {
+		return s.configLines[sectionName][key]
+	}
+	return s.credentialsLines[sectionName][key]
+} //keyLine
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+} //indexOf
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//errors returned by the Store which can be checked with errors.Is
+var (
+	ErrProfileNotFound       = errors.New("profile not found")
+	ErrFileUnreadable        = errors.New("file unreadable")
+	ErrWriteFailed           = errors.New("write failed")
+	ErrDefaultNotActivatable = errors.New("default profile not activatable")
+	ErrProfileExists         = errors.New("profile exists")
+	ErrProfileActive         = errors.New("profile active")
+	ErrBackupNotFound        = errors.New("backup not found")
+	ErrBackupAmbiguous       = errors.New("backup ambiguous")
+)
+
+//FileError is returned if the credentials or config file can't be read or written.
+//Kind is ErrFileUnreadable or ErrWriteFailed, Err the error of the underlying operation.
+type FileError struct {
+	Kind error
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	if e.Kind == ErrWriteFailed {
+		return fmt.Sprintf("Failed to write file: %s. %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("Failed to find or read file: %s. %v", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FileError) Is(target error) bool {
+	return target == e.Kind
+}
+
+//ProfileError is returned for operations on a Profile which are not possible.
+//Kind is ErrProfileNotFound, ErrDefaultNotActivatable, ErrProfileExists or ErrProfileActive.
+type ProfileError struct {
+	Kind        error
+	ProfileName string
+}
+
+func (e *ProfileError) Error() string {
+	switch e.Kind {
+	case ErrDefaultNotActivatable:
+		return fmt.Sprintf("Cannot activate the '%s' Profile as it is already active", e.ProfileName)
+	case ErrProfileExists:
+		return fmt.Sprintf("Profile '%s' already exists", e.ProfileName)
+	case ErrProfileActive:
+		return fmt.Sprintf("Profile '%s' is active", e.ProfileName)
+	}
+	return fmt.Sprintf("Profile '%s' does not exist", e.ProfileName)
+}
+
+func (e *ProfileError) Is(target error) bool {
+	return target == e.Kind
+}
+
+//BackupError is returned if FindBackup finds no or more than one backup.
+//Kind is ErrBackupNotFound or ErrBackupAmbiguous, SectionNames the backups a timestamp matches.
+type BackupError struct {
+	Kind         error
+	Ref          string
+	SectionNames []string
+}
+
+func (e *BackupError) Error() string {
+	if e.Kind == ErrBackupAmbiguous {
+		return fmt.Sprintf("Backup '%s' is ambiguous, it matches %s", e.Ref, strings.Join(e.SectionNames, " and "))
+	}
+	return fmt.Sprintf("Backup '%s' does not exist", e.Ref)
+}
+
+func (e *BackupError) Is(target error) bool {
+	return target == e.Kind
+}
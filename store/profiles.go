@@ -0,0 +1,183 @@
+package store
+
+import (
+	"fmt"
+	"github.com/BernhardLenz/ini"
+	"sort"
+	"strings"
+)
+
+//hasProfileSections returns true if there is a section of the given Profile in the credentials or config file,
+//including config sections with only region or output which are not listed as profiles
+func (s *Store) hasProfileSections(name string) bool {
+	if _, ok := s.profiles[name]; ok {
+		return true
+	}
+	if _, ok := s.configs[name]; ok {
+		return true
+	}
+	return s.HasCredentialsSection(name)
+} //hasProfileSections
+
+//validateNewProfileName checks that a Profile of the given name can be created
+func (s *Store) validateNewProfileName(name string) error {
+	if name == "" || strings.ContainsAny(name, "[] \t\n") {
+		return fmt.Errorf("invalid Profile name '%s'", name)
+	}
+	if _, ok := parseBackupSectionName(name); ok || name == DefaultSection {
+		return fmt.Errorf("the Profile name '%s' is reserved", name)
+	}
+	if s.hasProfileSections(name) {
+		return &ProfileError{ErrProfileExists, name}
+	}
+	return nil
+} //validateNewProfileName
+
+//AddProfile creates a Profile with static credentials in the credentials file and the given keys, e.g. region
+//or role_arn, in a [profile NAME] section of the config file. Empty credentials or settings don't create a section.
+//The files are not saved.
+func (s *Store) AddProfile(name string, creds Credentials, settings []Setting) error {
+	if err := s.validateNewProfileName(name); err != nil {
+		return err
+	}
+
+	if creds.AccessKeyId != "" {
+		section, err := s.credentialsFile.NewSection(name)
+		if err != nil {
+			return err
+		}
+		if err := setKeys(section, creds, false); err != nil {
+			return err
+		}
+		s.credentialsModified = true
+	}
+
+	if len(settings) > 0 {
+		section, err := s.configFile.NewSection(configSectionProfile + " " + name)
+		if err != nil {
+			return err
+		}
+		for _, setting := range settings {
+			if _, err := section.NewKey(setting.Name, setting.Value); err != nil {
+				return err
+			}
+		}
+		s.configModified = true
+	}
+
+	s.parse()
+	return nil
+} //AddProfile
+
+//CopyProfile copies the sections of a Profile in the credentials and config file into a new Profile.
+//The files are not saved.
+func (s *Store) CopyProfile(fromName string, toName string) error {
+	if fromName == DefaultSection || !s.hasProfileSections(fromName) {
+		return &ProfileError{ErrProfileNotFound, fromName}
+	}
+	if err := s.validateNewProfileName(toName); err != nil {
+		return err
+	}
+
+	if fromSection, err := s.credentialsFile.GetSection(fromName); err == nil {
+		if err := copySection(s.credentialsFile, fromSection, toName); err != nil {
+			return err
+		}
+		s.credentialsModified = true
+	}
+
+	if config, ok := s.configs[fromName]; ok {
+		//legacy sections without the profile prefix are copied as they are
+		toSectionName := configSectionProfile + " " + toName
+		if config.SectionName == fromName {
+			toSectionName = toName
+		}
+		if err := copySection(s.configFile, s.configFile.Section(config.SectionName), toSectionName); err != nil {
+			return err
+		}
+		s.configModified = true
+	}
+
+	s.parse()
+	return nil
+} //CopyProfile
+
+//RenameProfile renames the sections of a Profile in the credentials and config file and updates the
+//source_profile keys referencing it. The sections are moved to the end of the files.
+//The names of the config sections whose source_profile has been updated are returned. The files are not saved.
+func (s *Store) RenameProfile(oldName string, newName string) ([]string, error) {
+	if err := s.CopyProfile(oldName, newName); err != nil {
+		return nil, err
+	}
+
+	s.credentialsFile.DeleteSection(oldName)
+	if config, ok := s.configs[oldName]; ok {
+		s.configFile.DeleteSection(config.SectionName)
+	}
+
+	updated := make([]string, 0)
+	for _, section := range s.configFile.Sections() {
+		if keyValue(section, "source_profile") == oldName {
+			section.Key("source_profile").SetValue(newName)
+			updated = append(updated, section.Name())
+			s.configModified = true
+		}
+	}
+
+	s.parse()
+	return updated, nil
+} //RenameProfile
+
+//RemoveProfile deletes the sections of a Profile from the credentials and config file. The active Profile
+//is only removed if force is true, the default section keeps its keys then and is backed up by the next Activate.
+//The files are not saved.
+func (s *Store) RemoveProfile(name string, force bool) error {
+	profile, ok := s.profiles[name]
+	if name == DefaultSection || !s.hasProfileSections(name) {
+		return &ProfileError{ErrProfileNotFound, name}
+	}
+	if ok && profile.IsActive && !force {
+		return &ProfileError{ErrProfileActive, name}
+	}
+
+	if s.HasCredentialsSection(name) {
+		s.credentialsFile.DeleteSection(name)
+		s.credentialsModified = true
+	}
+	if config, ok := s.configs[name]; ok {
+		s.configFile.DeleteSection(config.SectionName)
+		s.configModified = true
+	}
+
+	s.parse()
+	return nil
+} //RemoveProfile
+
+//ProfileReferences returns the names of the profiles whose source_profile is the given Profile
+func (s *Store) ProfileReferences(name string) []string {
+	references := make([]string, 0)
+	for configName, config := range s.configs {
+		if config.SourceProfile == name && configName != name {
+			references = append(references, configName)
+		}
+	}
+	sort.Strings(references)
+	return references
+} //ProfileReferences
+
+//copySection copies all keys including their comments into a new section of the same file
+func copySection(file *ini.File, fromSection *ini.Section, toSectionName string) error {
+	toSection, err := file.NewSection(toSectionName)
+	if err != nil {
+		return err
+	}
+	toSection.Comment = fromSection.Comment
+	for _, key := range fromSection.Keys() {
+		toKey, err := toSection.NewKey(key.Name(), key.Value())
+		if err != nil {
+			return err
+		}
+		toKey.Comment = key.Comment
+	}
+	return nil
+} //copySection
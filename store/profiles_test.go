@@ -0,0 +1,158 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+//setupProfilesTest loads copies of the config_only_profiles files which can be saved
+func setupProfilesTest(t *testing.T) *Store {
+	s := newTestStore()
+	s.credentialsPath = copyTestdata(t, "config_only_profiles_credentials", 0600)
+	s.configPath = copyTestdata(t, "config_only_profiles_config", 0600)
+	s.testLoad(t)
+	return s
+} //setupProfilesTest
+
+func TestAddProfile(t *testing.T) {
+	s := setupProfilesTest(t)
+
+	creds := Credentials{AccessKeyId: "AKIAADDED00000000001", SecretAccessKey: "secret"}
+	if err := s.AddProfile("personal", creds, []Setting{{Name: "region", Value: "eu-west-1"}}); err != nil {
+		t.Fatalf("TestAddProfile: AddProfile failed: %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("TestAddProfile: Save failed: %v", err)
+	}
+	s.testLoad(t)
+
+	profile, ok := s.profiles["personal"]
+	if !ok || profile.Kind != KindStatic || profile.AwsAccessKeyId != creds.AccessKeyId || profile.Region != "eu-west-1" {
+		t.Errorf("TestAddProfile: unexpected Profile after saving: %v", profile)
+		t.Fail()
+	}
+	if config, _ := s.Config("personal"); config.SectionName != "profile personal" {
+		t.Errorf("TestAddProfile: config section is not 'profile personal': %s", config.SectionName)
+		t.Fail()
+	}
+
+	if err := s.AddProfile("prod", creds, nil); !errors.Is(err, ErrProfileExists) {
+		t.Errorf("TestAddProfile: ErrProfileExists not returned for an existing Profile: %v", err)
+		t.Fail()
+	}
+	//config sections with only a region are not listed but exist
+	if err := s.AddProfile("region-only", creds, nil); !errors.Is(err, ErrProfileExists) {
+		t.Errorf("TestAddProfile: ErrProfileExists not returned for a region only config: %v", err)
+		t.Fail()
+	}
+	for _, name := range []string{DefaultSection, "default-20210414093000", "my profile", ""} {
+		if err := s.AddProfile(name, creds, nil); err == nil {
+			t.Errorf("TestAddProfile: invalid Profile name '%s' accepted", name)
+			t.Fail()
+		}
+	}
+} //TestAddProfile
+
+func TestRenameProfile(t *testing.T) {
+	s := setupProfilesTest(t)
+
+	updated, err := s.RenameProfile("prod", "production")
+	if err != nil {
+		t.Fatalf("TestRenameProfile: RenameProfile failed: %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("TestRenameProfile: Save failed: %v", err)
+	}
+	s.testLoad(t)
+
+	if len(updated) != 1 || updated[0] != "profile admin" {
+		t.Errorf("TestRenameProfile: unexpected updated sections: %v", updated)
+		t.Fail()
+	}
+	if _, ok := s.profiles["prod"]; ok {
+		t.Errorf("TestRenameProfile: prod Profile still exists")
+		t.Fail()
+	}
+	if profile := s.profiles["production"]; profile.AwsAccessKeyId != "12345678901234567890" || profile.Region != "eu-central-1" {
+		t.Errorf("TestRenameProfile: unexpected production Profile: %v", profile)
+		t.Fail()
+	}
+	if config, _ := s.Config("admin"); config.SourceProfile != "production" {
+		t.Errorf("TestRenameProfile: source_profile of admin is not 'production': %s", config.SourceProfile)
+		t.Fail()
+	}
+
+	if _, err := s.RenameProfile("missing", "other"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("TestRenameProfile: ErrProfileNotFound not returned for a missing Profile: %v", err)
+		t.Fail()
+	}
+	if _, err := s.RenameProfile("production", "admin"); !errors.Is(err, ErrProfileExists) {
+		t.Errorf("TestRenameProfile: ErrProfileExists not returned for an existing Profile: %v", err)
+		t.Fail()
+	}
+} //TestRenameProfile
+
+func TestCopyProfile(t *testing.T) {
+	s := setupProfilesTest(t)
+
+	if err := s.CopyProfile("sso-dev", "sso-test"); err != nil {
+		t.Fatalf("TestCopyProfile: CopyProfile failed: %v", err)
+	}
+
+	if s.credentialsModified {
+		t.Errorf("TestCopyProfile: credentials file modified by copying a config only Profile")
+		t.Fail()
+	}
+	for _, name := range []string{"sso-dev", "sso-test"} {
+		if profile := s.profiles[name]; profile.Kind != KindSso || profile.Region != "eu-west-1" {
+			t.Errorf("TestCopyProfile: unexpected %s Profile: %v", name, profile)
+			t.Fail()
+		}
+	}
+} //TestCopyProfile
+
+func TestRemoveProfile(t *testing.T) {
+	s := setupProfilesTest(t)
+
+	if err := s.Activate("prod"); err != nil {
+		t.Fatalf("TestRemoveProfile: Activate failed: %v", err)
+	}
+
+	if references := s.ProfileReferences("prod"); len(references) != 1 || references[0] != "admin" {
+		t.Errorf("TestRemoveProfile: unexpected references of prod: %v", references)
+		t.Fail()
+	}
+
+	if err := s.RemoveProfile("prod", false); !errors.Is(err, ErrProfileActive) {
+		t.Errorf("TestRemoveProfile: ErrProfileActive not returned for the active Profile: %v", err)
+		t.Fail()
+	}
+	if err := s.RemoveProfile("prod", true); err != nil {
+		t.Fatalf("TestRemoveProfile: RemoveProfile failed: %v", err)
+	}
+	if err := s.RemoveProfile("vault", false); err != nil {
+		t.Fatalf("TestRemoveProfile: RemoveProfile failed: %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("TestRemoveProfile: Save failed: %v", err)
+	}
+	s.testLoad(t)
+
+	for _, name := range []string{"prod", "vault"} {
+		if _, ok := s.Config(name); ok || s.HasCredentialsSection(name) {
+			t.Errorf("TestRemoveProfile: sections of %s have not been removed", name)
+			t.Fail()
+		}
+	}
+
+	//the default section keeps the keys of the removed Profile
+	if profile, ok := s.profiles[DefaultSection]; !ok || !profile.IsActive {
+		t.Errorf("TestRemoveProfile: default Profile is not active after removing the active Profile")
+		t.Fail()
+	}
+
+	if err := s.RemoveProfile(DefaultSection, true); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("TestRemoveProfile: ErrProfileNotFound not returned for the default Profile: %v", err)
+		t.Fail()
+	}
+} //TestRemoveProfile
@@ -0,0 +1,222 @@
+package store
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+//copyTestdata copies a file from testdata into a temporary directory so it can be modified
+func copyTestdata(t *testing.T, fileName string, perm os.FileMode) string {
+	content, err := ioutil.ReadFile(filepath.Join("..", "testdata", fileName))
+	if err != nil {
+		t.Fatalf("copyTestdata: %v", err)
+	}
+	target := filepath.Join(t.TempDir(), fileName)
+	if err := ioutil.WriteFile(target, content, perm); err != nil {
+		t.Fatalf("copyTestdata: %v", err)
+	}
+	return target
+} //copyTestdata
+
+func TestActivateSavesToCredentialsFilePath(t *testing.T) {
+	s := newTestStore()
+
+	credentialsFileName := copyTestdata(t, "mfa_credentials", 0600)
+	s.credentialsPath = credentialsFileName
+	//activating prod copies its mfa_serial into the default config which is saved as well
+	s.configPath = copyTestdata(t, "mfa_config", 0600)
+	s.testLoad(t)
+
+	if err := s.Activate("prod"); err != nil {
+		t.Fatalf("TestActivateSavesToCredentialsFilePath: Activate failed: %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("TestActivateSavesToCredentialsFilePath: Save failed: %v", err)
+	}
+
+	if !s.profiles["prod"].IsActive {
+		t.Errorf("TestActivateSavesToCredentialsFilePath: prod Profile is not active after activation")
+		t.Fail()
+	}
+
+	content, _ := ioutil.ReadFile(credentialsFileName)
+	if !strings.Contains(string(content), "[default]\naws_access_key_id     = 12345678901234567891") {
+		t.Errorf("TestActivateSavesToCredentialsFilePath: default section has not been written:\n%s", content)
+		t.Fail()
+	}
+
+	//the standalone default Profile has been backed up
+	if !strings.Contains(string(content), "[default-") {
+		t.Errorf("TestActivateSavesToCredentialsFilePath: no backup of the default section:\n%s", content)
+		t.Fail()
+	}
+
+	info, _ := os.Stat(credentialsFileName)
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("TestActivateSavesToCredentialsFilePath: file mode is not 0600: %v", info.Mode().Perm())
+		t.Fail()
+	}
+
+	entries, _ := ioutil.ReadDir(filepath.Dir(credentialsFileName))
+	if len(entries) != 1 {
+		t.Errorf("TestActivateSavesToCredentialsFilePath: temporary files have been left behind: %d files", len(entries))
+		t.Fail()
+	}
+} //TestActivateSavesToCredentialsFilePath
+
+func TestActivateWriteError(t *testing.T) {
+	s := newTestStore()
+
+	credentialsFileName := copyTestdata(t, "mfa_credentials", 0600)
+	s.credentialsPath = credentialsFileName
+	s.configPath = copyTestdata(t, "mfa_config", 0600)
+	s.testLoad(t)
+
+	//the directory of the credentials file disappears before it is saved
+	s.credentialsPath = filepath.Join(credentialsFileName, "missing", "credentials")
+
+	if err := s.Activate("prod"); err != nil {
+		t.Fatalf("TestActivateWriteError: Activate failed: %v", err)
+	}
+	if err := s.Save(); !errors.Is(err, ErrWriteFailed) {
+		t.Errorf("TestActivateWriteError: ErrWriteFailed not returned for an unwritable credentials file: %v", err)
+		t.Fail()
+	}
+} //TestActivateWriteError
+
+func TestActivateErrors(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/mfa_credentials"
+	s.configPath = "../testdata/mfa_config"
+	s.testLoad(t)
+
+	if err := s.Activate(DefaultSection); !errors.Is(err, ErrDefaultNotActivatable) {
+		t.Errorf("TestActivateErrors: ErrDefaultNotActivatable not returned for the default Profile: %v", err)
+		t.Fail()
+	}
+
+	if err := s.Activate("missing"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("TestActivateErrors: ErrProfileNotFound not returned for a missing Profile: %v", err)
+		t.Fail()
+	}
+
+	if s.credentialsModified || s.configModified {
+		t.Errorf("TestActivateErrors: files have been modified by a failed Activate")
+		t.Fail()
+	}
+} //TestActivateErrors
+
+func TestSaveCreatesMissingFiles(t *testing.T) {
+	awsDir := filepath.Join(t.TempDir(), ".aws")
+	credentialsFileName := filepath.Join(awsDir, "credentials")
+	configFileName := copyTestdata(t, "role_chain_config", 0600)
+
+	s, err := Load(credentialsFileName, configFileName)
+	if err != nil {
+		t.Fatalf("TestSaveCreatesMissingFiles: Load failed: %v", err)
+	}
+
+	//a config only Profile clears the default section of the missing credentials file
+	if err := s.Activate("admin"); err != nil {
+		t.Fatalf("TestSaveCreatesMissingFiles: Activate failed: %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("TestSaveCreatesMissingFiles: Save failed: %v", err)
+	}
+
+	if len(s.MissingFiles()) != 0 {
+		t.Errorf("TestSaveCreatesMissingFiles: files are still missing after Save: %v", s.MissingFiles())
+		t.Fail()
+	}
+
+	info, err := os.Stat(credentialsFileName)
+	if err != nil {
+		t.Fatalf("TestSaveCreatesMissingFiles: credentials file has not been created: %v", err)
+	}
+	if runtime.GOOS != "windows" {
+		dirInfo, _ := os.Stat(awsDir)
+		if info.Mode().Perm() != 0600 || dirInfo.Mode().Perm() != 0700 {
+			t.Errorf("TestSaveCreatesMissingFiles: unexpected modes %v of the credentials file and %v of its directory",
+				info.Mode().Perm(), dirInfo.Mode().Perm())
+			t.Fail()
+		}
+	}
+
+	s, err = Load(credentialsFileName, configFileName)
+	if err != nil {
+		t.Fatalf("TestSaveCreatesMissingFiles: created credentials file can't be loaded: %v", err)
+	}
+	if s.DefaultConfig().RoleArn != "arn:aws:iam::123456789012:role/Admin" {
+		t.Errorf("TestSaveCreatesMissingFiles: role_arn of admin not copied into the default config: %s", s.DefaultConfig().RoleArn)
+		t.Fail()
+	}
+
+	//keys which are only read must not be written as empty values
+	if content, _ := ioutil.ReadFile(configFileName); strings.Contains(string(content), "sso_session") {
+		t.Errorf("TestSaveCreatesMissingFiles: empty keys have been added to the config file: %s", content)
+		t.Fail()
+	}
+} //TestSaveCreatesMissingFiles
+
+func TestSaveKeepsUnmodifiedFilesMissing(t *testing.T) {
+	awsDir := filepath.Join(t.TempDir(), ".aws")
+	credentialsFileName := filepath.Join(awsDir, "credentials")
+	configFileName := filepath.Join(awsDir, "config")
+
+	s, err := Load(credentialsFileName, configFileName)
+	if err != nil {
+		t.Fatalf("TestSaveKeepsUnmodifiedFilesMissing: Load failed: %v", err)
+	}
+
+	if err := s.SetDefaultCredentials(Credentials{AccessKeyId: "ASIATEMPORARY0001", SecretAccessKey: "secret1", SessionToken: "token1"}); err != nil {
+		t.Fatalf("TestSaveKeepsUnmodifiedFilesMissing: SetDefaultCredentials failed: %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("TestSaveKeepsUnmodifiedFilesMissing: Save failed: %v", err)
+	}
+
+	if _, err := os.Stat(credentialsFileName); err != nil {
+		t.Errorf("TestSaveKeepsUnmodifiedFilesMissing: credentials file has not been created: %v", err)
+		t.Fail()
+	}
+
+	//the config file is not needed for static credentials
+	if _, err := os.Stat(configFileName); !os.IsNotExist(err) {
+		t.Errorf("TestSaveKeepsUnmodifiedFilesMissing: unmodified config file has been created: %v", err)
+		t.Fail()
+	}
+} //TestSaveKeepsUnmodifiedFilesMissing
+
+func TestWriteFileAtomicKeepsPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on windows")
+	}
+
+	fileName := filepath.Join(t.TempDir(), "credentials")
+	_ = ioutil.WriteFile(fileName, []byte("old"), 0640)
+
+	if err := WriteFileAtomic(fileName, []byte("new")); err != nil {
+		t.Fatalf("TestWriteFileAtomicKeepsPermissions: WriteFileAtomic failed: %v", err)
+	}
+
+	content, _ := ioutil.ReadFile(fileName)
+	info, _ := os.Stat(fileName)
+	if string(content) != "new" || info.Mode().Perm() != 0640 {
+		t.Errorf("TestWriteFileAtomicKeepsPermissions: unexpected content '%s' or mode %v", content, info.Mode().Perm())
+		t.Fail()
+	}
+
+	newFileName := filepath.Join(filepath.Dir(fileName), "config")
+	_ = WriteFileAtomic(newFileName, []byte("new"))
+	info, _ = os.Stat(newFileName)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("TestWriteFileAtomicKeepsPermissions: new file mode is not 0600: %v", info.Mode().Perm())
+		t.Fail()
+	}
+} //TestWriteFileAtomicKeepsPermissions
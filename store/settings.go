@@ -0,0 +1,120 @@
+package store
+
+import (
+	"github.com/BernhardLenz/ini"
+	"strings"
+)
+
+//files a Setting can be read from
+const (
+	FileCredentials = "credentials"
+	FileConfig      = "config"
+)
+
+//Setting is a key of a Profile in the credentials or config file, including the ones awsenv doesn't
+//interpret itself like ca_bundle, endpoint_url, retry_mode or cli_pager. Nested sub-sections such as
+//
+//	s3 =
+//	  max_concurrent_requests = 20
+//
+//are kept as SubSettings of the s3 Setting, whose Value is empty then.
+type Setting struct {
+	Name  string
+	Value string
+	File  string
+	//Line is the line number of the key in the file or 0 if it has been added since the file was read
+	Line        int
+	SubSettings []Setting
+}
+
+//lineIndex maps section names to the line numbers of their keys. The keys of nested sub-sections
+//are stored as "parent.key", e.g. "s3.max_concurrent_requests".
+type lineIndex map[string]map[string]int
+
+//Setting returns a key of the Profile. Keys in the credentials file take precedence over the config file.
+func (p Profile) Setting(name string) (Setting, bool) {
+	for _, setting := range p.Settings {
+		if setting.Name == name {
+			return setting, true
+		}
+	}
+	return Setting{}, false
+} //Setting
+
+//SettingValue returns the value of a key of the Profile or "" if it isn't set
+func (p Profile) SettingValue(name string) string {
+	setting, _ := p.Setting(name)
+	return setting.Value
+} //SettingValue
+
+//readSettings returns all keys of a section in the order of the file
+func readSettings(section *ini.Section, file string, lines lineIndex) []Setting {
+	sectionLines := lines[section.Name()]
+	settings := make([]Setting, 0, len(section.Keys()))
+	for _, key := range section.Keys() {
+		setting := Setting{Name: key.Name(), Value: key.Value(), File: file, Line: sectionLines[key.Name()]}
+		if strings.Contains(setting.Value, "\n") {
+			setting.SubSettings = parseSubSettings(setting.Value, file)
+			for i := range setting.SubSettings {
+				setting.SubSettings[i].Line = sectionLines[setting.Name+"."+setting.SubSettings[i].Name]
+			}
+			setting.Value = ""
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+} //readSettings
+
+//indexLines finds the line numbers of the keys in the content of a file. Like the ini library
+//a later key of the same name in a section, or a later section of the same name, wins.
+func indexLines(content []byte) lineIndex {
+	lines := make(lineIndex)
+	sectionName := DefaultSection
+	parentKey := ""
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			sectionName = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			parentKey = ""
+			continue
+		}
+		parts := strings.SplitN(trimmed, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		keyName := strings.TrimSpace(parts[0])
+		indented := line != strings.TrimLeft(line, " \t")
+		if indented && parentKey != "" {
+			keyName = parentKey + "." + keyName
+		} else {
+			parentKey = ""
+			if strings.TrimSpace(parts[1]) == "" {
+				parentKey = keyName
+			}
+		}
+		if lines[sectionName] == nil {
+			lines[sectionName] = make(map[string]int)
+		}
+		lines[sectionName][keyName] = i + 1
+	}
+	return lines
+} //indexLines
+
+//parseSubSettings parses the indented key = value lines of a nested sub-section
+func parseSubSettings(value string, file string) []Setting {
+	subSettings := make([]Setting, 0)
+	for _, line := range strings.Split(value, "\n") {
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 {
+			subSettings = append(subSettings, Setting{
+				Name:  strings.TrimSpace(parts[0]),
+				Value: strings.TrimSpace(parts[1]),
+				File:  file,
+			})
+		}
+	}
+	return subSettings
+} //parseSubSettings
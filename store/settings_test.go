@@ -0,0 +1,139 @@
+package store
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProfileSettings(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/all_settings_credentials"
+	s.configPath = "../testdata/all_settings_config"
+	s.testLoad(t)
+
+	expectedKinds := map[string]string{
+		"default": KindStatic,
+		"prod":    KindStatic,
+		"admin":   KindRole,
+		"web":     KindWebIdentity,
+		"sso":     KindSso,
+		"tool":    KindProcess,
+	}
+	for name, kind := range expectedKinds {
+		if profile := s.profiles[name]; profile.Kind != kind {
+			t.Errorf("TestProfileSettings: %s Profile.Kind is not '%s': %s ", name, kind, profile.Kind)
+			t.Fail()
+		}
+	}
+
+	prod := s.profiles["prod"]
+	names := make([]string, 0)
+	for _, setting := range prod.Settings {
+		names = append(names, setting.File+":"+setting.Name)
+	}
+	expectedNames := []string{
+		"credentials:aws_access_key_id", "credentials:aws_secret_access_key", "credentials:aws_session_token",
+		"config:region", "config:ca_bundle", "config:endpoint_url", "config:retry_mode", "config:max_attempts",
+		"config:cli_pager", "config:s3",
+	}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("TestProfileSettings: prod Profile.Settings are not %v: %v", expectedNames, names)
+		t.Fail()
+	}
+
+	s3, _ := prod.Setting("s3")
+	expectedS3 := []Setting{
+		{Name: "max_concurrent_requests", Value: "20", File: FileConfig, Line: 13},
+		{Name: "addressing_style", Value: "path", File: FileConfig, Line: 14},
+	}
+	if s3.Value != "" || !reflect.DeepEqual(s3.SubSettings, expectedS3) {
+		t.Errorf("TestProfileSettings: s3 sub-section of prod is not %v: %v", expectedS3, s3)
+		t.Fail()
+	}
+
+	expectedLines := map[string]int{"aws_access_key_id": 6, "aws_session_token": 8, "region": 6, "cli_pager": 11, "s3": 12}
+	for _, setting := range prod.Settings {
+		if line, ok := expectedLines[setting.Name]; ok && setting.Line != line {
+			t.Errorf("TestProfileSettings: %s of prod is not in line %d: %d", setting.Name, line, setting.Line)
+			t.Fail()
+		}
+	}
+
+	if prod.SettingValue("ca_bundle") != "/etc/ssl/certs/corp.pem" || prod.SettingValue("missing") != "" {
+		t.Errorf("TestProfileSettings: unexpected SettingValue of prod: %v", prod.Settings)
+		t.Fail()
+	}
+
+	if admin := s.profiles["admin"]; admin.SettingValue("external_id") != "ext-1" || admin.SettingValue("mfa_serial") == "" {
+		t.Errorf("TestProfileSettings: keys of the config only admin Profile are missing: %v", admin.Settings)
+		t.Fail()
+	}
+
+	if s.defaultProfile.SettingValue("output") != "json" || s.defaultProfile.SettingValue("aws_access_key_id") == "" {
+		t.Errorf("TestProfileSettings: default Profile.Settings don't contain both files: %v", s.defaultProfile.Settings)
+		t.Fail()
+	}
+} //TestProfileSettings
+
+//withoutLines returns a copy of the settings without the line numbers, which change if keys are added
+func withoutLines(settings []Setting) []Setting {
+	copied := make([]Setting, 0, len(settings))
+	for _, setting := range settings {
+		setting.Line = 0
+		setting.SubSettings = withoutLines(setting.SubSettings)
+		copied = append(copied, setting)
+	}
+	return copied
+} //withoutLines
+
+func TestActivatePreservesSettings(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = copyTestdata(t, "all_settings_credentials", 0600)
+	s.configPath = copyTestdata(t, "all_settings_config", 0600)
+	s.testLoad(t)
+
+	before := s.Profiles()
+
+	activate := func(profileName string) {
+		if err := s.Activate(profileName); err != nil {
+			t.Fatalf("TestActivatePreservesSettings: Activate(%s) failed: %v", profileName, err)
+		}
+		if err := s.Save(); err != nil {
+			t.Fatalf("TestActivatePreservesSettings: Save failed: %v", err)
+		}
+		s.testLoad(t)
+	}
+
+	activate("prod")
+
+	//the session token of prod is copied into the default section as well
+	if s.CredentialsValue(DefaultSection, "aws_session_token") != "token1" {
+		t.Errorf("TestActivatePreservesSettings: aws_session_token of prod not copied into the default section")
+		t.Fail()
+	}
+
+	activate("admin")
+	activate("tool")
+
+	//only the activation state of the profiles changes, default isn't listed anymore as it matched prod
+	for _, profile := range before {
+		after, ok := s.profiles[profile.Name]
+		if profile.Name == DefaultSection {
+			continue
+		}
+		if !ok || !reflect.DeepEqual(withoutLines(after.Settings), withoutLines(profile.Settings)) {
+			t.Errorf("TestActivatePreservesSettings: Settings of %s changed from %v to %v", profile.Name, profile.Settings, after.Settings)
+			t.Fail()
+		}
+	}
+
+	content, _ := ioutil.ReadFile(s.configPath)
+	if !strings.Contains(string(content), "\n  max_concurrent_requests = 20\n  addressing_style = path\n") {
+		t.Errorf("TestActivatePreservesSettings: s3 sub-section of prod not preserved: \n%s", content)
+		t.Fail()
+	}
+} //TestActivatePreservesSettings
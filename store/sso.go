@@ -0,0 +1,157 @@
+package store
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//SsoCacheDir returns the directory the aws cli caches the IAM Identity Center tokens in.
+//It is a method pointer which can be changed during test case execution.
+var SsoCacheDir = func() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "sso", "cache")
+}
+
+//SsoToken has the same format as the token files the aws cli writes to ~/.aws/sso/cache.
+//The client registration is kept in the file as well so it can be reused by the next login.
+type SsoToken struct {
+	StartUrl              string    `json:"startUrl"`
+	Region                string    `json:"region"`
+	AccessToken           string    `json:"accessToken"`
+	ExpiresAt             time.Time `json:"expiresAt"`
+	ClientId              string    `json:"clientId,omitempty"`
+	ClientSecret          string    `json:"clientSecret,omitempty"`
+	RegistrationExpiresAt time.Time `json:"registrationExpiresAt"`
+	RefreshToken          string    `json:"refreshToken,omitempty"`
+}
+
+//SsoLogin describes where to log in for a Profile or sso-session. SessionName is empty for legacy
+//profiles which have sso_start_url and sso_region in their own section.
+type SsoLogin struct {
+	SessionName string
+	StartUrl    string
+	Region      string
+	Scopes      []string
+}
+
+//CacheKey computes the same token file name as the aws cli, i.e. the sha1 of the sso-session name
+//or of the start url for legacy profiles
+func (l SsoLogin) CacheKey() string {
+	key := l.StartUrl
+	if l.SessionName != "" {
+		key = l.SessionName
+	}
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:])
+} //CacheKey
+
+func ssoTokenFileName(login SsoLogin) string {
+	return filepath.Join(SsoCacheDir(), login.CacheKey()+".json")
+} //ssoTokenFileName
+
+//ReadSsoToken returns the cached token of a login, which may have expired. False is returned if there is none.
+func ReadSsoToken(login SsoLogin) (SsoToken, bool) {
+	content, err := ioutil.ReadFile(ssoTokenFileName(login))
+	if err != nil {
+		return SsoToken{}, false
+	}
+	var token SsoToken
+	if err := json.Unmarshal(content, &token); err != nil {
+		return SsoToken{}, false
+	}
+	return token, true
+} //ReadSsoToken
+
+//IsValid returns true if the token can still be used for a while
+func (t SsoToken) IsValid() bool {
+	return t.AccessToken != "" && time.Until(t.ExpiresAt) >= cacheExpiryWindow
+} //IsValid
+
+//WriteSsoToken writes the token file of a login only readable by the user
+func WriteSsoToken(login SsoLogin, token SsoToken) error {
+	content, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(SsoCacheDir(), 0700); err != nil {
+		return err
+	}
+	return WriteFileAtomic(ssoTokenFileName(login), content)
+} //WriteSsoToken
+
+//SsoLogin returns where to log in for an sso-session or for a Profile using an sso-session
+//or legacy sso_start_url. sso-sessions take precedence over profiles of the same name.
+func (s *Store) SsoLogin(name string) (SsoLogin, error) {
+	sessionName := name
+	if _, ok := s.ssoSessions[name]; !ok {
+		config, ok := s.configs[name]
+		if !ok || configKind(config) != KindSso {
+			return SsoLogin{}, &ProfileError{ErrProfileNotFound, name}
+		}
+		if config.SsoSession == "" {
+			if config.SsoStartUrl == "" || config.SsoRegion == "" {
+				return SsoLogin{}, fmt.Errorf("Profile '%s' has no sso_start_url or sso_region", name)
+			}
+			return SsoLogin{StartUrl: config.SsoStartUrl, Region: config.SsoRegion}, nil
+		}
+		sessionName = config.SsoSession
+	}
+
+	session, ok := s.ssoSessions[sessionName]
+	if !ok {
+		return SsoLogin{}, fmt.Errorf("sso-session '%s' does not exist", sessionName)
+	}
+	if session.SsoStartUrl == "" || session.SsoRegion == "" {
+		return SsoLogin{}, fmt.Errorf("sso-session '%s' has no sso_start_url or sso_region", sessionName)
+	}
+	login := SsoLogin{SessionName: sessionName, StartUrl: session.SsoStartUrl, Region: session.SsoRegion}
+	for _, scope := range strings.Split(session.SsoRegistrationScopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			login.Scopes = append(login.Scopes, scope)
+		}
+	}
+	return login, nil
+} //SsoLogin
+
+//SsoSessions returns the names of all sso-sessions
+func (s *Store) SsoSessions() []string {
+	names := make([]string, 0, len(s.ssoSessions))
+	for name := range s.ssoSessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+} //SsoSessions
+
+//SsoProfileName returns the name of the Profile using a role of an account via an sso-session, or false
+//if there is none yet
+func (s *Store) SsoProfileName(sessionName string, accountId string, roleName string) (string, bool) {
+	for name, config := range s.configs {
+		if name != DefaultSection && config.SsoSession == sessionName && config.SsoAccountId == accountId && config.SsoRoleName == roleName {
+			return name, true
+		}
+	}
+	return "", false
+} //SsoProfileName
+
+//AddSsoProfile creates a Profile using a role of an account via an sso-session. The region is only set
+//if it isn't empty, otherwise the Profile uses the default config. The config file is not saved.
+func (s *Store) AddSsoProfile(name string, sessionName string, accountId string, roleName string, region string) error {
+	settings := []Setting{
+		{Name: "sso_session", Value: sessionName},
+		{Name: "sso_account_id", Value: accountId},
+		{Name: "sso_role_name", Value: roleName},
+	}
+	if region != "" {
+		settings = append(settings, Setting{Name: "region", Value: region})
+	}
+	return s.AddProfile(name, Credentials{}, settings)
+} //AddSsoProfile
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSsoTokenCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	origSsoCacheDir := SsoCacheDir
+	t.Cleanup(func() { SsoCacheDir = origSsoCacheDir })
+	SsoCacheDir = func() string { return cacheDir }
+
+	//the aws cli names the token file after the sso-session or, for legacy profiles, the start url
+	session := SsoLogin{SessionName: "my-sso", StartUrl: "https://my-sso-portal.awsapps.com/start"}
+	legacy := SsoLogin{StartUrl: "https://my-sso-portal.awsapps.com/start"}
+	if session.CacheKey() != "0ad374308c5a4e22f723adf10145eafad7c4031c" || legacy.CacheKey() != "c7aaaf71fcc8777ae2475525ed049d39fe16c484" {
+		t.Errorf("TestSsoTokenCache: unexpected cache keys %s and %s", session.CacheKey(), legacy.CacheKey())
+		t.Fail()
+	}
+
+	if _, ok := ReadSsoToken(session); ok {
+		t.Errorf("TestSsoTokenCache: token found before it has been written")
+		t.Fail()
+	}
+
+	token := SsoToken{StartUrl: session.StartUrl, Region: "us-east-1", AccessToken: "token1", ExpiresAt: time.Now().Add(time.Hour).UTC().Truncate(time.Second)}
+	if err := WriteSsoToken(session, token); err != nil {
+		t.Fatalf("TestSsoTokenCache: WriteSsoToken failed: %v", err)
+	}
+	read, ok := ReadSsoToken(session)
+	if !ok || !read.IsValid() || read.AccessToken != "token1" || !read.ExpiresAt.Equal(token.ExpiresAt) {
+		t.Errorf("TestSsoTokenCache: unexpected token read from the cache: %v", read)
+		t.Fail()
+	}
+
+	token.ExpiresAt = time.Now().Add(time.Minute)
+	if token.IsValid() {
+		t.Errorf("TestSsoTokenCache: token about to expire is valid")
+		t.Fail()
+	}
+} //TestSsoTokenCache
@@ -0,0 +1,659 @@
+//Package store reads and writes the credentials and config files of the aws cli.
+//A Store holds the parsed profiles of both files and applies changes like activating a Profile
+//in memory until Save writes them back.
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/BernhardLenz/ini"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+//name of the default section in both files
+const DefaultSection = "default"
+
+//kinds of profiles depending on how the credentials are obtained
+const (
+	KindStatic      = "static"
+	KindRole        = "role"
+	KindSso         = "sso"
+	KindProcess     = "process"
+	KindWebIdentity = "web-identity"
+)
+
+//section name prefixes used in the config file
+const (
+	configSectionProfile    = "profile"
+	configSectionSsoSession = "sso-session"
+	configSectionServices   = "services"
+)
+
+type Profile struct {
+	Name               string
+	Kind               string
+	AwsAccessKeyId     string
+	AwsSecretAccessKey string
+	AwsSessionToken    string
+	Expiration         time.Time
+	Output             string
+	Region             string
+	//IsActive is true if the Profile is used by default, i.e. it matches the default section
+	IsActive bool
+	//IsShellActive is true if the Profile is selected by AWS_PROFILE
+	IsShellActive bool
+	//Settings holds all keys of the Profile, the ones of the credentials file first
+	Settings []Setting
+}
+
+type Config struct {
+	SectionName       string
+	Output            string
+	Region            string
+	RoleArn           string
+	SourceProfile     string
+	CredentialSource  string
+	SsoSession        string
+	SsoStartUrl       string
+	SsoRegion         string
+	SsoAccountId      string
+	SsoRoleName       string
+	CredentialProcess string
+	WebIdentityToken  string
+	MfaSerial         string
+	ExternalId        string
+	RoleSessionName   string
+	DurationSeconds   string
+}
+
+type SsoSession struct {
+	SessionName           string
+	SsoStartUrl           string
+	SsoRegion             string
+	SsoRegistrationScopes string
+}
+
+//Credentials are the keys used to sign requests.
+//The json names match the format of the aws cli cache files.
+type Credentials struct {
+	AccessKeyId     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"SessionToken,omitempty"`
+	Expiration      time.Time `json:"Expiration,omitempty"`
+}
+
+type Store struct {
+	credentialsPath string
+	configPath      string
+	credentialsFile *ini.File
+	configFile      *ini.File
+
+	//only modified files are written by Save
+	credentialsModified bool
+	configModified      bool
+
+	//missing files are treated as empty and created by Save if they are modified
+	credentialsMissing bool
+	configMissing      bool
+
+	//timestamp of the backups made since the last Save
+	backupTime time.Time
+
+	//line numbers of the keys in the files as they have been read or last saved
+	credentialsLines lineIndex
+	configLines      lineIndex
+
+	defaultProfile Profile
+	profiles       map[string]Profile
+	defaultConfig  Config
+	//configs are keyed by Profile name, i.e. without the "profile " prefix of the section name
+	configs     map[string]Config
+	ssoSessions map[string]SsoSession
+	//services maps the name of a [services NAME] section to its sub-sections,
+	//e.g. services["local"]["s3"]["endpoint_url"]
+	services map[string]map[string]map[string]string
+	//backups are sorted from newest to oldest
+	backups []Backup
+}
+
+func init() {
+	ini.DefaultSection = DefaultSection
+}
+
+//DefaultCredentialsPath returns the credentials file used by the aws cli
+func DefaultCredentialsPath() string {
+	return awsCliFilePath("AWS_SHARED_CREDENTIALS_FILE", "credentials")
+} //DefaultCredentialsPath
+
+//DefaultConfigPath returns the config file used by the aws cli
+func DefaultConfigPath() string {
+	return awsCliFilePath("AWS_CONFIG_FILE", "config")
+} //DefaultConfigPath
+
+func awsCliFilePath(env string, fileName string) string {
+	if value := os.Getenv(env); value != "" {
+		return value
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", fileName)
+} //awsCliFilePath
+
+//Load reads the credentials and the config file. Files which don't exist are treated as empty,
+//e.g. there is no credentials file on machines only using sso.
+func Load(credentialsPath string, configPath string) (*Store, error) {
+	s := &Store{credentialsPath: credentialsPath, configPath: configPath}
+	if err := s.loadCredentials(); err != nil {
+		return nil, err
+	}
+	if err := s.loadConfig(); err != nil {
+		return nil, err
+	}
+	s.parse()
+	return s, nil
+} //Load
+
+func (s *Store) loadCredentials() error {
+	var err error
+	s.credentialsFile, s.credentialsLines, s.credentialsMissing, err = loadFile(ini.LoadOptions{}, s.credentialsPath)
+	return err
+} //loadCredentials
+
+//loadConfig allows python style multiline values as the config file may contain nested sub-sections
+func (s *Store) loadConfig() error {
+	var err error
+	s.configFile, s.configLines, s.configMissing, err = loadFile(ini.LoadOptions{AllowPythonMultilineValues: true}, s.configPath)
+	return err
+} //loadConfig
+
+//loadFile returns the parsed file and the line numbers of its keys, or an empty file and true if the file doesn't exist
+func loadFile(options ini.LoadOptions, fileName string) (*ini.File, lineIndex, bool, error) {
+	if _, err := os.Stat(fileName); os.IsNotExist(err) {
+		return ini.Empty(options), nil, true, nil
+	}
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, nil, false, &FileError{ErrFileUnreadable, fileName, err}
+	}
+	file, err := ini.LoadSources(options, content)
+	if err != nil {
+		return nil, nil, false, &FileError{ErrFileUnreadable, fileName, err}
+	}
+	return file, indexLines(content), false, nil
+} //loadFile
+
+//parse builds the profiles from the loaded files. It is called again after every change.
+func (s *Store) parse() {
+	s.reset()
+	s.parseCredentials()
+	s.parseConfig()
+	s.markShellActiveProfile()
+} //parse
+
+func (s *Store) reset() {
+	s.defaultProfile = Profile{}
+	s.profiles = make(map[string]Profile)
+	s.defaultConfig = Config{}
+	s.configs = make(map[string]Config)
+	s.ssoSessions = make(map[string]SsoSession)
+	s.services = make(map[string]map[string]map[string]string)
+	s.backups = make([]Backup, 0)
+} //reset
+
+func (s *Store) parseCredentials() {
+	//creates new empty defaultCredentialsSection if it doesn't exist already
+	defaultCredentialsSection := s.credentialsFile.Section(DefaultSection)
+	s.defaultProfile.Name = DefaultSection
+	s.defaultProfile.AwsAccessKeyId = keyValue(defaultCredentialsSection, "aws_access_key_id")
+	s.defaultProfile.AwsSecretAccessKey = keyValue(defaultCredentialsSection, "aws_secret_access_key")
+	s.defaultProfile.AwsSessionToken = keyValue(defaultCredentialsSection, "aws_session_token")
+	s.defaultProfile.Expiration, _ = time.Parse(time.RFC3339, keyValue(defaultCredentialsSection, "expiration"))
+	s.defaultProfile.Settings = readSettings(defaultCredentialsSection, FileCredentials, s.credentialsLines)
+
+	for _, credentialsSection := range s.credentialsFile.Sections() {
+		sectionName := credentialsSection.Name()
+
+		var profile Profile
+		profile.Name = sectionName
+		profile.Kind = KindStatic
+		profile.Settings = readSettings(credentialsSection, FileCredentials, s.credentialsLines)
+
+		for _, key := range credentialsSection.Keys() {
+			keyName := key.Name()
+			value := key.Value()
+			if "aws_access_key_id" == keyName {
+				profile.AwsAccessKeyId = value
+			} else if "aws_secret_access_key" == keyName {
+				profile.AwsSecretAccessKey = value
+			} else if "aws_session_token" == keyName {
+				profile.AwsSessionToken = value
+			} else if "expiration" == keyName {
+				profile.Expiration, _ = time.Parse(time.RFC3339, value)
+			}
+		}
+		//backups of the default section are not listed as profiles
+		if timestamp, ok := parseBackupSectionName(sectionName); ok {
+			s.addBackup(credentialsSection, timestamp, FileCredentials)
+			continue
+		}
+
+		if DefaultSection != sectionName {
+			s.profiles[sectionName] = profile
+		}
+	}
+
+	//Now mark the profiles that match the default as active
+	activeProfileFound := false
+	for profileName, profile := range s.profiles {
+		if profile.AwsAccessKeyId == s.defaultProfile.AwsAccessKeyId && profile.AwsAccessKeyId != "" {
+			profile.IsActive = true
+			s.profiles[profileName] = profile
+			activeProfileFound = true
+		}
+	}
+
+	//if there is no matching Profile then add the default Profile to the profiles map and make it active
+	if !activeProfileFound && s.defaultProfile.AwsAccessKeyId != "" {
+		s.defaultProfile.IsActive = true
+		s.defaultProfile.Kind = KindStatic
+		s.profiles[DefaultSection] = s.defaultProfile
+	}
+} //parseCredentials
+
+func (s *Store) parseConfig() {
+	s.defaultConfig = readConfigSection(s.configFile.Section(DefaultSection))
+
+	//a [profile NAME] section takes precedence over a legacy [NAME] section of the same Profile
+	prefixedSections := make(map[string]bool)
+	settings := make(map[string][]Setting)
+
+	for _, configSection := range s.configFile.Sections() {
+		sectionName := configSection.Name()
+
+		sectionType, name := splitConfigSectionName(sectionName)
+		switch sectionType {
+		case configSectionSsoSession:
+			s.parseSsoSession(name, configSection)
+			continue
+		case configSectionServices:
+			s.parseServices(name, configSection)
+			continue
+		case configSectionProfile:
+			prefixedSections[name] = true
+		default:
+			if prefixedSections[name] {
+				continue
+			}
+			//backups of the region and output of the default config
+			if timestamp, ok := parseBackupSectionName(sectionName); ok {
+				s.addBackup(configSection, timestamp, FileConfig)
+				continue
+			}
+		}
+
+		s.configs[name] = readConfigSection(configSection)
+		settings[name] = readSettings(configSection, FileConfig, s.configLines)
+	}
+
+	//Now set the corresponding fields in profiles
+	for sectionName, profile := range s.profiles {
+		if config, ok := s.configs[sectionName]; ok {
+			profile.Output = config.Output
+			profile.Region = config.Region
+			profile.Settings = append(profile.Settings, settings[sectionName]...)
+			//credentials obtained via the config file take precedence over static keys
+			if kind := configKind(config); kind != "" {
+				profile.Kind = kind
+			}
+			s.profiles[sectionName] = profile
+		}
+	}
+	s.defaultProfile.Output = s.defaultConfig.Output
+	s.defaultProfile.Region = s.defaultConfig.Region
+	s.defaultProfile.Settings = append(s.defaultProfile.Settings, settings[DefaultSection]...)
+
+	//add the profiles which only exist in the config file, e.g. role, sso or credential_process profiles
+	for name, config := range s.configs {
+		if _, ok := s.profiles[name]; ok || name == DefaultSection {
+			continue
+		}
+		kind := configKind(config)
+		if kind == "" {
+			//only region or output without any credentials
+			continue
+		}
+		profile := Profile{
+			Name:     name,
+			Kind:     kind,
+			Output:   config.Output,
+			Region:   config.Region,
+			Settings: settings[name],
+			IsActive: s.defaultProfile.AwsAccessKeyId == "" && hasSameCredentialSource(config, s.defaultConfig),
+		}
+
+		//roles which have been assumed by awsenv are active if their cached credentials are in the default section
+		if kind == KindRole {
+			if creds, ok := ReadCachedRoleCredentials(config); ok {
+				profile = WithCredentials(profile, creds)
+				if creds.AccessKeyId == s.defaultProfile.AwsAccessKeyId {
+					profile.IsActive = true
+					if s.defaultProfile.IsActive {
+						s.defaultProfile.IsActive = false
+						delete(s.profiles, DefaultSection)
+					}
+				}
+			}
+		}
+		s.profiles[name] = profile
+	}
+} //parseConfig
+
+//markShellActiveProfile marks the Profile selected by AWS_PROFILE in the current shell
+func (s *Store) markShellActiveProfile() {
+	shellProfileName := os.Getenv("AWS_PROFILE")
+	if profile, ok := s.profiles[shellProfileName]; ok {
+		profile.IsShellActive = true
+		s.profiles[shellProfileName] = profile
+	}
+} //markShellActiveProfile
+
+//WithCredentials returns a copy of the Profile using the given, usually temporary, credentials
+func WithCredentials(profile Profile, creds Credentials) Profile {
+	profile.AwsAccessKeyId = creds.AccessKeyId
+	profile.AwsSecretAccessKey = creds.SecretAccessKey
+	profile.AwsSessionToken = creds.SessionToken
+	profile.Expiration = creds.Expiration
+	return profile
+} //WithCredentials
+
+func readConfigSection(section *ini.Section) Config {
+	return Config{
+		SectionName:       section.Name(),
+		Output:            keyValue(section, "output"),
+		Region:            keyValue(section, "region"),
+		RoleArn:           keyValue(section, "role_arn"),
+		SourceProfile:     keyValue(section, "source_profile"),
+		CredentialSource:  keyValue(section, "credential_source"),
+		SsoSession:        keyValue(section, "sso_session"),
+		SsoStartUrl:       keyValue(section, "sso_start_url"),
+		SsoRegion:         keyValue(section, "sso_region"),
+		SsoAccountId:      keyValue(section, "sso_account_id"),
+		SsoRoleName:       keyValue(section, "sso_role_name"),
+		CredentialProcess: keyValue(section, "credential_process"),
+		WebIdentityToken:  keyValue(section, "web_identity_token_file"),
+		MfaSerial:         keyValue(section, "mfa_serial"),
+		ExternalId:        keyValue(section, "external_id"),
+		RoleSessionName:   keyValue(section, "role_session_name"),
+		DurationSeconds:   keyValue(section, "duration_seconds"),
+	}
+} //readConfigSection
+
+//keyValue returns the value of a key or "" if it doesn't exist. Unlike Section.Key it doesn't add
+//the key to the section, which would otherwise be written as an empty value by Save.
+func keyValue(section *ini.Section, keyName string) string {
+	if !section.HasKey(keyName) {
+		return ""
+	}
+	return section.Key(keyName).Value()
+} //keyValue
+
+//configKind returns the kind of credentials a config section provides or "" if it doesn't provide any
+func configKind(config Config) string {
+	if config.SsoSession != "" || config.SsoStartUrl != "" || config.SsoAccountId != "" {
+		return KindSso
+	}
+	if config.RoleArn != "" && config.WebIdentityToken != "" {
+		return KindWebIdentity
+	}
+	if config.RoleArn != "" {
+		return KindRole
+	}
+	if config.CredentialProcess != "" {
+		return KindProcess
+	}
+	return ""
+} //configKind
+
+//hasSameCredentialSource checks if two config sections obtain their credentials the same way
+func hasSameCredentialSource(a Config, b Config) bool {
+	if configKind(a) == "" {
+		return false
+	}
+	return a.RoleArn == b.RoleArn &&
+		a.SourceProfile == b.SourceProfile &&
+		a.CredentialSource == b.CredentialSource &&
+		a.SsoSession == b.SsoSession &&
+		a.SsoStartUrl == b.SsoStartUrl &&
+		a.SsoAccountId == b.SsoAccountId &&
+		a.SsoRoleName == b.SsoRoleName &&
+		a.CredentialProcess == b.CredentialProcess &&
+		a.WebIdentityToken == b.WebIdentityToken
+} //hasSameCredentialSource
+
+//splitConfigSectionName splits a config file section name like "profile prod" or "sso-session my-sso"
+//into its type and name. [default] and legacy sections without a prefix are treated as profiles
+//and returned with an empty type.
+func splitConfigSectionName(sectionName string) (string, string) {
+	fields := strings.Fields(sectionName)
+	if len(fields) == 2 {
+		switch fields[0] {
+		case configSectionProfile, configSectionSsoSession, configSectionServices:
+			return fields[0], fields[1]
+		}
+	}
+	return "", sectionName
+} //splitConfigSectionName
+
+func (s *Store) parseSsoSession(sessionName string, section *ini.Section) {
+	s.ssoSessions[sessionName] = SsoSession{
+		SessionName:           sessionName,
+		SsoStartUrl:           keyValue(section, "sso_start_url"),
+		SsoRegion:             keyValue(section, "sso_region"),
+		SsoRegistrationScopes: keyValue(section, "sso_registration_scopes"),
+	}
+} //parseSsoSession
+
+//parseServices reads the nested service sub-sections of a [services NAME] section such as
+//
+//	s3 =
+//	  endpoint_url = http://localhost:9000
+//
+//which are loaded as multiline values of the service key
+func (s *Store) parseServices(servicesName string, section *ini.Section) {
+	serviceMap := make(map[string]map[string]string)
+	for _, key := range section.Keys() {
+		settings := make(map[string]string)
+		for _, subSetting := range parseSubSettings(key.Value(), FileConfig) {
+			settings[subSetting.Name] = subSetting.Value
+		}
+		serviceMap[key.Name()] = settings
+	}
+	s.services[servicesName] = serviceMap
+} //parseServices
+
+//Profiles returns all profiles sorted by name
+func (s *Store) Profiles() []Profile {
+	profiles := make([]Profile, 0, len(s.profiles))
+	for _, profile := range s.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+} //Profiles
+
+func (s *Store) Profile(name string) (Profile, bool) {
+	profile, ok := s.profiles[name]
+	return profile, ok
+} //Profile
+
+//DefaultProfile returns the content of the default section of the credentials file
+func (s *Store) DefaultProfile() Profile {
+	return s.defaultProfile
+} //DefaultProfile
+
+//CredentialsPath returns the path the credentials file has been read from
+func (s *Store) CredentialsPath() string {
+	return s.credentialsPath
+} //CredentialsPath
+
+//ConfigPath returns the path the config file has been read from
+func (s *Store) ConfigPath() string {
+	return s.configPath
+} //ConfigPath
+
+//Config returns the config section of a Profile
+func (s *Store) Config(name string) (Config, bool) {
+	config, ok := s.configs[name]
+	return config, ok
+} //Config
+
+func (s *Store) Configs() map[string]Config {
+	configs := make(map[string]Config, len(s.configs))
+	for name, config := range s.configs {
+		configs[name] = config
+	}
+	return configs
+} //Configs
+
+//DefaultConfig returns the [default] section of the config file
+func (s *Store) DefaultConfig() Config {
+	return s.defaultConfig
+} //DefaultConfig
+
+func (s *Store) SsoSession(name string) (SsoSession, bool) {
+	session, ok := s.ssoSessions[name]
+	return session, ok
+} //SsoSession
+
+//Services returns the settings of a [services NAME] section by service, e.g. Services("local")["s3"]["endpoint_url"]
+func (s *Store) Services(name string) (map[string]map[string]string, bool) {
+	serviceMap, ok := s.services[name]
+	return serviceMap, ok
+} //Services
+
+//MissingFiles returns the paths of the credentials and config file if they don't exist
+func (s *Store) MissingFiles() []string {
+	missing := make([]string, 0)
+	if s.credentialsMissing {
+		missing = append(missing, s.credentialsPath)
+	}
+	if s.configMissing {
+		missing = append(missing, s.configPath)
+	}
+	return missing
+} //MissingFiles
+
+//HasCredentialsSection returns true if the credentials file contains a section with the given name
+func (s *Store) HasCredentialsSection(sectionName string) bool {
+	_, err := s.credentialsFile.GetSection(sectionName)
+	return err == nil
+} //HasCredentialsSection
+
+//CredentialsValue returns the value of a key in the credentials file, e.g. of keys other tools put there
+func (s *Store) CredentialsValue(sectionName string, keyName string) string {
+	section, err := s.credentialsFile.GetSection(sectionName)
+	if err != nil {
+		return ""
+	}
+	return keyValue(section, keyName)
+} //CredentialsValue
+
+//StaticCredentials returns the keys of a Profile in the credentials file
+func (s *Store) StaticCredentials(profileName string) (Credentials, error) {
+	profile, ok := s.profiles[profileName]
+	if profileName == s.defaultProfile.Name && !ok {
+		profile, ok = s.defaultProfile, true
+	}
+	if !ok {
+		return Credentials{}, &ProfileError{ErrProfileNotFound, profileName}
+	}
+	if profile.AwsAccessKeyId == "" {
+		return Credentials{}, fmt.Errorf("Profile '%s' has no aws_access_key_id", profileName)
+	}
+	return Credentials{
+		AccessKeyId:     profile.AwsAccessKeyId,
+		SecretAccessKey: profile.AwsSecretAccessKey,
+		SessionToken:    profile.AwsSessionToken,
+		Expiration:      profile.Expiration,
+	}, nil
+} //StaticCredentials
+
+//Save writes the modified files to the locations they have been read from. Missing files are created
+//only readable by the user, like their directory, e.g. ~/.aws. Errors are returned as FileError of kind ErrWriteFailed.
+func (s *Store) Save() error {
+	if s.credentialsModified {
+		var buf bytes.Buffer
+		if _, err := s.credentialsFile.WriteTo(&buf); err != nil {
+			return &FileError{ErrWriteFailed, s.credentialsPath, err}
+		}
+		if err := os.MkdirAll(filepath.Dir(s.credentialsPath), 0700); err != nil {
+			return &FileError{ErrWriteFailed, s.credentialsPath, err}
+		}
+		if err := WriteFileAtomic(s.credentialsPath, buf.Bytes()); err != nil {
+			return &FileError{ErrWriteFailed, s.credentialsPath, err}
+		}
+		s.credentialsModified = false
+		s.credentialsMissing = false
+		s.credentialsLines = indexLines(buf.Bytes())
+	}
+	if s.configModified {
+		if err := os.MkdirAll(filepath.Dir(s.configPath), 0700); err != nil {
+			return &FileError{ErrWriteFailed, s.configPath, err}
+		}
+		content, err := s.saveConfigFile(s.configPath)
+		if err != nil {
+			return &FileError{ErrWriteFailed, s.configPath, err}
+		}
+		s.configModified = false
+		s.configMissing = false
+		s.configLines = indexLines(content)
+	}
+	//the line numbers of the settings have changed
+	s.backupTime = time.Time{}
+	s.parse()
+	return nil
+} //Save
+
+//WriteFileAtomic writes to a temporary file in the same directory which is then renamed, so the file is
+//never left half written. The permissions of an existing file are kept, new files are only readable by the user.
+func WriteFileAtomic(fileName string, content []byte) error {
+	perm := os.FileMode(0600)
+	if info, err := os.Stat(fileName); err == nil {
+		perm = info.Mode().Perm()
+		//replace the target of a symlink instead of the symlink itself
+		if resolved, err := filepath.EvalSymlinks(fileName); err == nil {
+			fileName = resolved
+		}
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(fileName), "."+filepath.Base(fileName)+".tmp")
+	if err != nil {
+		return err
+	}
+	//only has an effect if anything fails before the rename
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Chmod(perm); err != nil && runtime.GOOS != "windows" {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), fileName)
+} //WriteFileAtomic
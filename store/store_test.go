@@ -0,0 +1,621 @@
+package store
+
+import (
+	"errors"
+	"github.com/BernhardLenz/ini"
+	"testing"
+)
+
+//newTestStore returns an empty Store whose files are loaded and parsed step by step by the test cases
+func newTestStore() *Store {
+	s := &Store{credentialsFile: ini.Empty(), configFile: ini.Empty()}
+	s.reset()
+	return s
+} //newTestStore
+
+func (s *Store) testParseCredentials(t *testing.T) {
+	if err := s.loadCredentials(); err != nil {
+		t.Fatalf("testParseCredentials: %v", err)
+	}
+	s.parseCredentials()
+} //testParseCredentials
+
+func (s *Store) testParseConfig(t *testing.T) {
+	if err := s.loadConfig(); err != nil {
+		t.Fatalf("testParseConfig: %v", err)
+	}
+	s.parseConfig()
+} //testParseConfig
+
+//testLoad loads both files like Load
+func (s *Store) testLoad(t *testing.T) {
+	if err := s.loadCredentials(); err != nil {
+		t.Fatalf("testLoad: %v", err)
+	}
+	if err := s.loadConfig(); err != nil {
+		t.Fatalf("testLoad: %v", err)
+	}
+	s.parse()
+} //testLoad
+
+func TestInvalidFile(t *testing.T) {
+	//a directory exists but can't be read as a file
+	if _, err := Load("../testdata", "../testdata/only_default_config"); !errors.Is(err, ErrFileUnreadable) {
+		t.Errorf("TestInvalidFile: Excepted an error for an invalid credentials file")
+		t.Fail()
+	}
+
+	if _, err := Load("../testdata/only_default_credentials", "../testdata"); !errors.Is(err, ErrFileUnreadable) {
+		t.Errorf("TestInvalidFile: Excepted an error for an invalid config file")
+		t.Fail()
+	}
+} //TestInvalidFile
+
+func TestMissingFiles(t *testing.T) {
+	tests := []struct {
+		credentialsPath string
+		configPath      string
+		profiles        int
+		missing         int
+	}{
+		{"../testdata/nonexisting_file", "../testdata/nonexisting_file", 0, 2},
+		{"../testdata/nonexisting_file", "../testdata/role_chain_config", 5, 1},
+		{"../testdata/mfa_credentials", "../testdata/nonexisting_file", 5, 1},
+		{"../testdata/mfa_credentials", "../testdata/mfa_config", 5, 0},
+	}
+
+	for _, test := range tests {
+		s, err := Load(test.credentialsPath, test.configPath)
+		if err != nil {
+			t.Errorf("TestMissingFiles: Load(%s, %s) failed: %v", test.credentialsPath, test.configPath, err)
+			t.Fail()
+			continue
+		}
+		if len(s.Profiles()) != test.profiles || len(s.MissingFiles()) != test.missing {
+			t.Errorf("TestMissingFiles: Load(%s, %s) returned %d profiles and %d missing files instead of %d and %d: %v",
+				test.credentialsPath, test.configPath, len(s.Profiles()), len(s.MissingFiles()), test.profiles, test.missing, s.MissingFiles())
+			t.Fail()
+		}
+	}
+} //TestMissingFiles
+
+func TestEmptyFile(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/empty_file"
+	s.testParseCredentials(t)
+
+	if s.defaultProfile.Name != "default" {
+		t.Errorf("TestEmptyFile: s.defaultProfile.Name is not 'default': %s ", s.defaultProfile.Name)
+		t.Fail()
+	}
+
+	if s.defaultProfile.AwsAccessKeyId != "" {
+		t.Errorf("TestEmptyFile: s.defaultProfile.AwsAccessKeyId is not '': %s ", s.defaultProfile.AwsAccessKeyId)
+		t.Fail()
+	}
+
+	if len(s.profiles) != 0 {
+		t.Errorf("TestEmptyFile: len(s.profiles) is not 0: %d ", len(s.profiles))
+		t.Fail()
+	}
+
+	s.configPath = "../testdata/empty_file"
+	s.testParseConfig(t)
+
+	if s.defaultConfig.Region != "" {
+		t.Errorf("TestEmptyFile: s.defaultConfig.Region is not '': %s ", s.defaultConfig.Region)
+		t.Fail()
+	}
+
+	if len(s.configs) != 1 {
+		t.Errorf("TestEmptyFile: len(s.configs) is not 1: %d ", len(s.configs))
+		t.Fail()
+	}
+
+	config := s.configs[ini.DefaultSection]
+
+	if config.Output != "" {
+		t.Errorf("TestOnlyDefaultSection: default config.Output is not '': %s ", config.Output)
+		t.Fail()
+	}
+
+	if config.Region != "" {
+		t.Errorf("TestOnlyDefaultSection: default config.Region is not '': %s ", config.Region)
+		t.Fail()
+	}
+
+} //TestEmptyFile
+
+func TestEmptyConfigFile(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/one_profile_matching_default_credentials"
+
+	s.testParseCredentials(t)
+
+	s.configPath = "../testdata/empty_file"
+	s.testParseConfig(t)
+
+	if s.defaultConfig.Output != "" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultConfig.Output is not '': %s ", s.defaultConfig.Output)
+		t.Fail()
+	}
+
+	if s.defaultConfig.Region != "" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultConfig.Region is not 'us-east-1': %s ", s.defaultConfig.Region)
+		t.Fail()
+	}
+
+	config := s.configs[ini.DefaultSection]
+	profile := s.profiles[ini.DefaultSection]
+
+	if config.Output != "" {
+		t.Errorf("TestOnlyDefaultSection: default config.Output is not '': %s ", config.Output)
+		t.Fail()
+	}
+
+	if config.Region != "" {
+		t.Errorf("TestOnlyDefaultSection: default config.Region is not '': %s ", config.Region)
+		t.Fail()
+	}
+
+	if s.defaultProfile.Region != "" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultProfile.Region is not '': %s ", s.defaultProfile.Region)
+		t.Fail()
+	}
+
+	if s.defaultProfile.Output != "" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultProfile.Output is not '': %s ", s.defaultProfile.Output)
+		t.Fail()
+	}
+
+	if profile.Region != "" {
+		t.Errorf("TestOnlyDefaultSection: default Profile.Region is not '': %s ", profile.Region)
+		t.Fail()
+	}
+
+	if profile.Output != "" {
+		t.Errorf("TestOnlyDefaultSection: default Profile.Output is not '': %s ", profile.Output)
+		t.Fail()
+	}
+} //TestOnlyDefaultSection
+
+func TestOnlyDefaultSection(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/only_default_credentials"
+
+	s.testParseCredentials(t)
+
+	if s.defaultProfile.Name != "default" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultProfile.Name is not 'one_profile_matching_default_credentials': %s ", s.defaultProfile.Name)
+		t.Fail()
+	}
+
+	if s.defaultProfile.AwsAccessKeyId != "12345678901234567890" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultProfile.AwsAccessKeyId is not '12345678901234567890': %s ", s.defaultProfile.AwsAccessKeyId)
+		t.Fail()
+	}
+
+	if !s.defaultProfile.IsActive {
+		t.Errorf("TestOnlyDefaultSection: s.defaultProfile.IsActive is not true: %t ", s.defaultProfile.IsActive)
+		t.Fail()
+	}
+
+	if s.defaultProfile.Region != "" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultProfile.Region is not '': %s ", s.defaultProfile.Region)
+		t.Fail()
+	}
+
+	if s.defaultProfile.Output != "" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultProfile.Output is not '': %s ", s.defaultProfile.Output)
+		t.Fail()
+	}
+
+	if len(s.profiles) != 1 {
+		t.Errorf("TestOnlyDefaultSection: len(s.profiles) is not 1: %d ", len(s.profiles))
+		t.Fail()
+	}
+
+	profile := s.profiles[ini.DefaultSection]
+
+	if profile.Name != "default" {
+		t.Errorf("TestOnlyDefaultSection: Profile.Name is not 'default': %s ", profile.Name)
+		t.Fail()
+	}
+
+	if profile.AwsAccessKeyId != "12345678901234567890" {
+		t.Errorf("TestOnlyDefaultSection: Profile.AwsAccessKeyId is not '12345678901234567890': %s ", profile.AwsAccessKeyId)
+		t.Fail()
+	}
+
+	if !profile.IsActive {
+		t.Errorf("TestOnlyDefaultSection: default Profile.IsActive is not true: %t ", profile.IsActive)
+		t.Fail()
+	}
+
+	if profile.Region != "" {
+		t.Errorf("TestOnlyDefaultSection: default Profile.Region is not '': %s ", profile.Region)
+		t.Fail()
+	}
+
+	if profile.Output != "" {
+		t.Errorf("TestOnlyDefaultSection: default Profile.Output is not '': %s ", profile.Output)
+		t.Fail()
+	}
+
+	s.configPath = "../testdata/only_default_config"
+	s.testParseConfig(t)
+
+	if s.defaultConfig.Output != "json" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultConfig.Output is not 'json': %s ", s.defaultConfig.Output)
+		t.Fail()
+	}
+
+	if s.defaultConfig.Region != "us-east-1" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultConfig.Region is not 'us-east-1': %s ", s.defaultConfig.Region)
+		t.Fail()
+	}
+
+	if len(s.configs) != 1 {
+		t.Errorf("TestOnlyDefaultSection: len(s.configs) is not 1: %d ", len(s.configs))
+		t.Fail()
+	}
+
+	config := s.configs[ini.DefaultSection]
+	profile = s.profiles[ini.DefaultSection]
+
+	if config.Output != "json" {
+		t.Errorf("TestOnlyDefaultSection: default config.Output is not 'json': %s ", config.Output)
+		t.Fail()
+	}
+
+	if config.Region != "us-east-1" {
+		t.Errorf("TestOnlyDefaultSection: default config.Region is not 'us-east-1': %s ", config.Region)
+		t.Fail()
+	}
+
+	if s.defaultProfile.Region != "us-east-1" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultProfile.Region is not 'us-east-1': %s ", s.defaultProfile.Region)
+		t.Fail()
+	}
+
+	if s.defaultProfile.Output != "json" {
+		t.Errorf("TestOnlyDefaultSection: s.defaultProfile.Output is not 'json': %s ", s.defaultProfile.Output)
+		t.Fail()
+	}
+
+	if profile.Region != "us-east-1" {
+		t.Errorf("TestOnlyDefaultSection: default Profile.Region is not 'us-east-1': %s ", profile.Region)
+		t.Fail()
+	}
+
+	if profile.Output != "json" {
+		t.Errorf("TestOnlyDefaultSection: default Profile.Output is not 'json': %s ", profile.Output)
+		t.Fail()
+	}
+} //TestOnlyDefaultSection
+
+func TestProfileMatchingDefault(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/one_profile_matching_default_credentials"
+
+	s.testParseCredentials(t)
+
+	if s.defaultProfile.Name != "default" {
+		t.Errorf("TestProfileMatchingDefault: s.defaultProfile.Name is not 'default': %s ", s.defaultProfile.Name)
+		t.Fail()
+	}
+
+	if s.defaultProfile.AwsAccessKeyId != "12345678901234567890" {
+		t.Errorf("TestProfileMatchingDefault: s.defaultProfile.AwsAccessKeyId is not '12345678901234567890': %s ", s.defaultProfile.AwsAccessKeyId)
+		t.Fail()
+	}
+
+	if s.defaultProfile.IsActive {
+		t.Errorf("TestProfileMatchingDefault: s.defaultProfile.IsActive is not false: %t ", s.defaultProfile.IsActive)
+		t.Fail()
+	}
+
+	if len(s.profiles) != 1 {
+		t.Errorf("TestProfileMatchingDefault: len(s.profiles) is not 1: %d \n %v", len(s.profiles), s.profiles)
+		t.Fail()
+	}
+
+	profile := s.profiles["profile_matching_default_credentials"]
+
+	if profile.Name != "profile_matching_default_credentials" {
+		t.Errorf("TestProfileMatchingDefault: profile_matching_default_credentials Profile.Name is not 'profile_matching_default_credentials': %s ", profile.Name)
+		t.Fail()
+	}
+
+	if profile.AwsAccessKeyId != "12345678901234567890" {
+		t.Errorf("TestProfileMatchingDefault: profile_matching_default_credentials Profile.AwsAccessKeyId is not '12345678901234567890': %s ", profile.AwsAccessKeyId)
+		t.Fail()
+	}
+
+	if !profile.IsActive {
+		t.Errorf("TestProfileMatchingDefault: profile_matching_default_credentials Profile.IsActive is not true: %t ", profile.IsActive)
+		t.Fail()
+	}
+} //TestProfileMatchingDefault
+
+func TestMultipleProfileMatchingDefault(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/two_profiles_matching_default_credentials"
+
+	s.testParseCredentials(t)
+
+	if s.defaultProfile.Name != "default" {
+		t.Errorf("TestMultipleProfileMatchingDefault: s.defaultProfile.Name is not 'default': %s ", s.defaultProfile.Name)
+		t.Fail()
+	}
+
+	if s.defaultProfile.IsActive {
+		t.Errorf("TestMultipleProfileMatchingDefault: s.defaultProfile.IsActive is not false: %t ", s.defaultProfile.IsActive)
+		t.Fail()
+	}
+
+	if len(s.profiles) != 3 {
+		t.Errorf("TestMultipleProfileMatchingDefault: len(s.profiles) is not 3: %d \n %v", len(s.profiles), s.profiles)
+		t.Fail()
+	}
+
+	profile := s.profiles["profile1_matching_default_credentials"]
+
+	if profile.Name != "profile1_matching_default_credentials" {
+		t.Errorf("TestMultipleProfileMatchingDefault: profile1_matching_default_credentials Profile.Name is not 'profile_matching_default_credentials': %s ", profile.Name)
+		t.Fail()
+	}
+
+	if !profile.IsActive {
+		t.Errorf("TestMultipleProfileMatchingDefault: profile1_matching_default_credentials Profile.IsActive is not true: %t ", profile.IsActive)
+		t.Fail()
+	}
+
+	profile = s.profiles["profile2_matching_default_credentials"]
+
+	if profile.Name != "profile2_matching_default_credentials" {
+		t.Errorf("TestMultipleProfileMatchingDefault: profile2_matching_default_credentials Profile.Name is not 'profile2_matching_default_credentials': %s ", profile.Name)
+		t.Fail()
+	}
+
+	if !profile.IsActive {
+		t.Errorf("TestMultipleProfileMatchingDefault: profile2_matching_default_credentials Profile.IsActive is not true: %t ", profile.IsActive)
+		t.Fail()
+	}
+
+	profile = s.profiles["profile3_not_matching_default_credentials"]
+
+	if profile.Name != "profile3_not_matching_default_credentials" {
+		t.Errorf("TestMultipleProfileMatchingDefault: profile3_not_matching_default_credentials Profile.Name is not 'profile3_matching_default_credentials': %s ", profile.Name)
+		t.Fail()
+	}
+
+	if profile.IsActive {
+		t.Errorf("TestMultipleProfileMatchingDefault: profile3_not_matching_default_credentials Profile.IsActive is true: %t ", profile.IsActive)
+		t.Fail()
+	}
+} //TestMultipleProfileMatchingDefault
+
+func TestDuplicateProfiles(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/duplicate_profiles_credentials"
+
+	s.testParseCredentials(t)
+
+	if s.defaultProfile.Name != "default" {
+		t.Errorf("TestDuplicateProfiles: s.defaultProfile.Name is not 'default': %s ", s.defaultProfile.Name)
+		t.Fail()
+	}
+
+	//ini picks the 2nd default profile
+	if s.defaultProfile.AwsAccessKeyId != "12345678901234567891" {
+		t.Errorf("TestDuplicateProfiles: s.defaultProfile.AwsAccessKeyId is not '12345678901234567891': %s ", s.defaultProfile.AwsAccessKeyId)
+		t.Fail()
+	}
+
+	if !s.defaultProfile.IsActive {
+		t.Errorf("TestDuplicateProfiles: s.defaultProfile.IsActive is not active: %t ", s.defaultProfile.IsActive)
+		t.Fail()
+	}
+
+	if len(s.profiles) != 2 {
+		t.Errorf("TestDuplicateProfiles: len(s.profiles) is not 2: %d \n %v", len(s.profiles), s.profiles)
+		t.Fail()
+	}
+
+	profile := s.profiles["duplicate"]
+
+	if profile.Name != "duplicate" {
+		t.Errorf("TestDuplicateProfiles: duplicate Profile.Name is not 'duplicate': %s ", profile.Name)
+		t.Fail()
+	}
+
+	//ini picks the 2nd duplicate profile
+	if profile.AwsAccessKeyId != "12345678901234567893" {
+		t.Errorf("TestDuplicateProfiles: duplicate Profile.AwsAccessKeyId is not '12345678901234567893': %s ", s.defaultProfile.AwsAccessKeyId)
+		t.Fail()
+	}
+
+	if profile.IsActive {
+		t.Errorf("TestDuplicateProfiles: duplicate Profile.IsActive is active: %t ", profile.IsActive)
+		t.Fail()
+	}
+
+} //TestDuplicateProfiles
+
+func TestDuplicateMixedCaseProfiles(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/duplicate_mixed_case_profiles_credentials"
+
+	s.testParseCredentials(t)
+
+	if s.defaultProfile.Name != "default" {
+		t.Errorf("TestDuplicateMixedCaseProfiles: s.defaultProfile.Name is not 'default': %s ", s.defaultProfile.Name)
+		t.Fail()
+	}
+
+	//awsenv configures ini picks the lower case default profile
+	if s.defaultProfile.AwsAccessKeyId != "12345678901234567890" {
+		t.Errorf("TestDuplicateMixedCaseProfiles: s.defaultProfile.AwsAccessKeyId is not '12345678901234567891': %s ", s.defaultProfile.AwsAccessKeyId)
+		t.Fail()
+	}
+
+	if !s.defaultProfile.IsActive {
+		t.Errorf("TestDuplicateMixedCaseProfiles: s.defaultProfile.IsActive is not active: %t ", s.defaultProfile.IsActive)
+		t.Fail()
+	}
+
+	if len(s.profiles) != 4 {
+		t.Errorf("TestDuplicateMixedCaseProfiles: len(s.profiles) is not 4 %d \n %v", len(s.profiles), s.profiles)
+		t.Fail()
+	}
+
+	profile := s.profiles["DEFAULT"]
+
+	if profile.Name != "DEFAULT" {
+		t.Errorf("TestDuplicateMixedCaseProfiles: DEFAULT Profile.Name is not 'DEFAULT': %s ", profile.Name)
+		t.Fail()
+	}
+
+	if profile.AwsAccessKeyId != "12345678901234567891" {
+		t.Errorf("TestDuplicateMixedCaseProfiles: DEFAULT Profile.AwsAccessKeyId is not '12345678901234567891': %s ", s.defaultProfile.AwsAccessKeyId)
+		t.Fail()
+	}
+
+	if profile.IsActive {
+		t.Errorf("TestDuplicateMixedCaseProfiles: DEFAULT Profile.IsActive is active: %t ", profile.IsActive)
+		t.Fail()
+	}
+
+	profile = s.profiles["duplicate"]
+
+	if profile.Name != "duplicate" {
+		t.Errorf("TestDuplicateMixedCaseProfiles: duplicate Profile.Name is not 'duplicate': %s ", profile.Name)
+		t.Fail()
+	}
+
+	if profile.AwsAccessKeyId != "12345678901234567892" {
+		t.Errorf("TestDuplicateMixedCaseProfiles: duplicate Profile.AwsAccessKeyId is not '12345678901234567892': %s ", profile.AwsAccessKeyId)
+		t.Fail()
+	}
+
+	if profile.IsActive {
+		t.Errorf("TestDuplicateMixedCaseProfiles: duplicate Profile.IsActive is active: %t ", profile.IsActive)
+		t.Fail()
+	}
+
+	profile = s.profiles["DUPLICATE"]
+
+	if profile.Name != "DUPLICATE" {
+		t.Errorf("TestDuplicateMixedCaseProfiles: DUPLICATE Profile.Name is not 'DUPLICATE': %s ", profile.Name)
+		t.Fail()
+	}
+
+	if profile.AwsAccessKeyId != "12345678901234567893" {
+		t.Errorf("TestDuplicateMixedCaseProfiles: DUPLICATE Profile.AwsAccessKeyId is not '12345678901234567892': %s ", profile.AwsAccessKeyId)
+		t.Fail()
+	}
+
+	if profile.IsActive {
+		t.Errorf("TestDuplicateMixedCaseProfiles: DUPLICATE Profile.IsActive is active: %t ", profile.IsActive)
+		t.Fail()
+	}
+
+} //TestDuplicateMixedCaseProfiles
+
+func TestProfileSectionsConfig(t *testing.T) {
+	s := newTestStore()
+
+	s.credentialsPath = "../testdata/profile_sections_credentials"
+	s.configPath = "../testdata/profile_sections_config"
+	s.testLoad(t)
+
+	if s.defaultConfig.Region != "us-east-1" {
+		t.Errorf("TestProfileSectionsConfig: s.defaultConfig.Region is not 'us-east-1': %s ", s.defaultConfig.Region)
+		t.Fail()
+	}
+
+	if _, ok := s.configs["profile prod"]; ok {
+		t.Errorf("TestProfileSectionsConfig: s.configs contains the raw section name 'profile prod'")
+		t.Fail()
+	}
+
+	profile := s.profiles["prod"]
+
+	if profile.Region != "eu-central-1" {
+		t.Errorf("TestProfileSectionsConfig: prod Profile.Region is not 'eu-central-1': %s ", profile.Region)
+		t.Fail()
+	}
+
+	if profile.Output != "text" {
+		t.Errorf("TestProfileSectionsConfig: prod Profile.Output is not 'text': %s ", profile.Output)
+		t.Fail()
+	}
+
+	if !profile.IsActive {
+		t.Errorf("TestProfileSectionsConfig: prod Profile.IsActive is not true: %t ", profile.IsActive)
+		t.Fail()
+	}
+
+	profile = s.profiles["dev"]
+
+	if profile.Region != "us-west-2" {
+		t.Errorf("TestProfileSectionsConfig: dev Profile.Region is not 'us-west-2': %s ", profile.Region)
+		t.Fail()
+	}
+
+	if profile.Output != "" {
+		t.Errorf("TestProfileSectionsConfig: dev Profile.Output is not '': %s ", profile.Output)
+		t.Fail()
+	}
+
+	//[profile legacy] wins over [legacy]
+	profile = s.profiles["legacy"]
+
+	if profile.Region != "eu-west-1" {
+		t.Errorf("TestProfileSectionsConfig: legacy Profile.Region is not 'eu-west-1': %s ", profile.Region)
+		t.Fail()
+	}
+
+	if len(s.configs) != 4 {
+		t.Errorf("TestProfileSectionsConfig: len(s.configs) is not 4: %d \n %v", len(s.configs), s.configs)
+		t.Fail()
+	}
+
+	ssoSession, ok := s.ssoSessions["my-sso"]
+	if !ok {
+		t.Fatalf("TestProfileSectionsConfig: sso-session 'my-sso' is missing: %v", s.ssoSessions)
+	}
+
+	if ssoSession.SsoStartUrl != "https://my-sso-portal.awsapps.com/start" {
+		t.Errorf("TestProfileSectionsConfig: SsoSession.SsoStartUrl is not 'https://my-sso-portal.awsapps.com/start': %s ", ssoSession.SsoStartUrl)
+		t.Fail()
+	}
+
+	if ssoSession.SsoRegion != "us-east-1" {
+		t.Errorf("TestProfileSectionsConfig: SsoSession.SsoRegion is not 'us-east-1': %s ", ssoSession.SsoRegion)
+		t.Fail()
+	}
+
+	if endpoint := s.services["local"]["s3"]["endpoint_url"]; endpoint != "http://localhost:9000" {
+		t.Errorf("TestProfileSectionsConfig: s.services local s3 endpoint_url is not 'http://localhost:9000': %s ", endpoint)
+		t.Fail()
+	}
+
+	if style := s.services["local"]["s3"]["addressing_style"]; style != "path" {
+		t.Errorf("TestProfileSectionsConfig: s.services local s3 addressing_style is not 'path': %s ", style)
+		t.Fail()
+	}
+
+	if endpoint := s.services["local"]["dynamodb"]["endpoint_url"]; endpoint != "http://localhost:8000" {
+		t.Errorf("TestProfileSectionsConfig: s.services local dynamodb endpoint_url is not 'http://localhost:8000': %s ", endpoint)
+		t.Fail()
+	}
+} //TestProfileSectionsConfig